@@ -0,0 +1,266 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"iter"
+)
+
+// augmenter keeps extra per-node state of a tree in step with its structure.
+// Its update method is called whenever the children of a node may have changed.
+type augmenter[K any, V any] interface {
+	update(node *Node[K, V])
+}
+
+// Augmented is a tree in which every node caches the aggregate of the entries
+// in its subtree, so aggregates over any key range are computed in O(log n).
+// The aggregate is defined by lift, which maps a single entry to an aggregate,
+// and combine, which must be associative. It does not need to be commutative:
+// aggregates are always combined in ascending key order. The zero Augmented
+// has no aggregate; use NewAugmented.
+type Augmented[K cmp.Ordered, V any, A any] struct {
+	tree       Tree[K, augmented[V, A]]
+	aggregator *aggregator[K, V, A]
+}
+
+// augmented is the value stored in the nodes of an Augmented tree: the value
+// of the entry and the aggregate of its subtree.
+type augmented[V any, A any] struct {
+	val V
+	agg A
+}
+
+func NewAugmented[K cmp.Ordered, V any, A any](combine func(A, A) A, lift func(K, V) A) *Augmented[K, V, A] {
+	if combine == nil || lift == nil {
+		panic("bst: called NewAugmented() with a nil function")
+	}
+	a := &Augmented[K, V, A]{aggregator: &aggregator[K, V, A]{combine: combine, lift: lift}}
+	a.tree.aug = a.aggregator
+	return a
+}
+
+func (a *Augmented[K, V, A]) Insert(key K, data V) {
+	a.checkInit("Insert")
+	a.tree.Insert(key, augmented[V, A]{val: data})
+}
+
+// Get returns the value of key and whether it is present.
+func (a *Augmented[K, V, A]) Get(key K) (val V, ok bool) {
+	a.checkInit("Get")
+	if node := find(a.tree.root, key); node != nil {
+		return node.data.val, true
+	}
+	return val, false
+}
+
+// Put sets the value for key, as Tree.Put does.
+func (a *Augmented[K, V, A]) Put(key K, val V) (old V, replaced bool) {
+	a.checkInit("Put")
+	if a.replace(a.tree.root, key, val, &old) {
+		return old, true
+	}
+	a.tree.Insert(key, augmented[V, A]{val: val})
+	return old, false
+}
+
+// Update calls fn with the current value for key, as Tree.Update does.
+func (a *Augmented[K, V, A]) Update(key K, fn func(old V, exists bool) (val V, keep bool)) {
+	a.checkInit("Update")
+	a.tree.Update(key, func(old augmented[V, A], exists bool) (augmented[V, A], bool) {
+		val, keep := fn(old.val, exists)
+		return augmented[V, A]{val: val}, keep
+	})
+}
+
+// Delete removes key and returns its value, if it was present.
+func (a *Augmented[K, V, A]) Delete(key K) (val V, ok bool) {
+	a.checkInit("Delete")
+	old, ok := a.tree.Delete(key)
+	return old.val, ok
+}
+
+func (a *Augmented[K, V, A]) Remove(key K) {
+	a.checkInit("Remove")
+	a.tree.Remove(key)
+}
+
+func (a *Augmented[K, V, A]) Height() int {
+	if a == nil {
+		return 0
+	}
+	return a.tree.Height()
+}
+
+func (a *Augmented[K, V, A]) Size() int {
+	if a == nil {
+		return 0
+	}
+	return a.tree.size
+}
+
+func (a *Augmented[K, V, A]) Keys() []K {
+	if a == nil {
+		return nil
+	}
+	return a.tree.Keys()
+}
+
+// All returns an iterator over the key-value pairs of the tree in ascending key order.
+func (a *Augmented[K, V, A]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if a == nil {
+			return
+		}
+		walk(a.tree.root, false, func(key K, data augmented[V, A]) bool {
+			return yield(key, data.val)
+		})
+	}
+}
+
+// Range returns an iterator over the key-value pairs with lo <= key <= hi in ascending key order.
+func (a *Augmented[K, V, A]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if a == nil || a.tree.root == nil {
+			return
+		}
+		walkRange(a.tree.root, lo, hi, func(key K, data augmented[V, A]) bool {
+			return yield(key, data.val)
+		})
+	}
+}
+
+// Split moves the keys less than key into left and the remaining keys into
+// right, as Tree.Split does. Both trees keep the aggregate of a.
+func (a *Augmented[K, V, A]) Split(key K) (left, right *Augmented[K, V, A]) {
+	a.checkInit("Split")
+	l, r := a.tree.Split(key)
+	left = &Augmented[K, V, A]{tree: *l, aggregator: a.aggregator}
+	right = &Augmented[K, V, A]{tree: *r, aggregator: a.aggregator}
+	return left, right
+}
+
+// Aggregate returns the aggregate of the entries with lo <= key <= hi. The
+// result ok is false if there are no such entries.
+func (a *Augmented[K, V, A]) Aggregate(lo, hi K) (agg A, ok bool) {
+	a.checkInit("Aggregate")
+	if a.tree.root == nil || cmp.Compare(lo, hi) > 0 {
+		return agg, false
+	}
+	return query(a.aggregator, a.tree.root, lo, hi, true, true)
+}
+
+// Validate checks the invariants of the tree as Tree.Validate does.
+func (a *Augmented[K, V, A]) Validate() error {
+	if a == nil {
+		return nil
+	}
+	return a.tree.Validate()
+}
+
+// replace sets the value of key in the subtree and recomputes the aggregates
+// on the way back up. It reports whether key was found.
+func (a *Augmented[K, V, A]) replace(node *Node[K, augmented[V, A]], key K, val V, old *V) bool {
+	if node == nil {
+		return false
+	}
+	if c := cmp.Compare(key, node.key); c < 0 {
+		if !a.replace(node.left, key, val, old) {
+			return false
+		}
+	} else if c > 0 {
+		if !a.replace(node.right, key, val, old) {
+			return false
+		}
+	} else {
+		*old, node.data.val = node.data.val, val
+	}
+	a.aggregator.update(node)
+	return true
+}
+
+func (a *Augmented[K, V, A]) checkInit(method string) {
+	if a == nil {
+		panic("bst: called " + method + "() on a nil tree")
+	}
+	if a.aggregator == nil {
+		panic("bst: called " + method + "() on a tree not created by NewAugmented()")
+	}
+}
+
+type aggregator[K any, V any, A any] struct {
+	combine func(A, A) A
+	lift    func(K, V) A
+}
+
+func (ag *aggregator[K, V, A]) update(node *Node[K, augmented[V, A]]) {
+	agg := ag.lift(node.key, node.data.val)
+	if node.left != nil {
+		agg = ag.combine(node.left.data.agg, agg)
+	}
+	if node.right != nil {
+		agg = ag.combine(agg, node.right.data.agg)
+	}
+	node.data.agg = agg
+}
+
+// query aggregates the entries of the subtree within [lo, hi]. Bounds that are
+// known to hold for the whole subtree are switched off, so below the node where
+// the paths to lo and hi diverge only one path is followed on each side and
+// every subtree hanging off it contributes its cached aggregate.
+func query[K cmp.Ordered, V any, A any](ag *aggregator[K, V, A], node *Node[K, augmented[V, A]], lo, hi K, checkLo, checkHi bool) (agg A, ok bool) {
+	if node == nil {
+		return agg, false
+	}
+	if !checkLo && !checkHi {
+		return node.data.agg, true
+	}
+	if checkLo && cmp.Compare(node.key, lo) < 0 {
+		return query(ag, node.right, lo, hi, checkLo, checkHi)
+	}
+	if checkHi && cmp.Compare(node.key, hi) > 0 {
+		return query(ag, node.left, lo, hi, checkLo, checkHi)
+	}
+
+	agg = ag.lift(node.key, node.data.val)
+	if left, ok := query(ag, node.left, lo, hi, checkLo, false); ok {
+		agg = ag.combine(left, agg)
+	}
+	if right, ok := query(ag, node.right, lo, hi, false, checkHi); ok {
+		agg = ag.combine(agg, right)
+	}
+	return agg, true
+}
+
+// refresh recomputes the augmented values on the path to key after its value
+// was changed in place.
+func (tree *Tree[K, V]) refresh(node *Node[K, V], key K) {
+	if tree.aug == nil || node == nil {
+		return
+	}
+	if c := cmp.Compare(key, node.key); c < 0 {
+		tree.refresh(node.left, key)
+	} else if c > 0 {
+		tree.refresh(node.right, key)
+	}
+	tree.aug.update(node)
+}
@@ -19,7 +19,9 @@
 	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 */
 
-// Package bst implements AVL balanced binary search tree.
+// Package bst implements balanced binary search trees: Tree, an AVL tree,
+// FuncTree, an AVL tree ordered by a comparison function, and RBTree, a
+// red-black tree.
 package bst
 
 import (
@@ -28,29 +30,66 @@ import (
 )
 
 type Tree[K cmp.Ordered, V any] struct {
+	avl[K, V]
+}
+
+// avl holds the state of an AVL tree that does not depend on how its keys are
+// ordered, together with the rebalancing code. Tree compares keys directly and
+// FuncTree through its comparison function; both embed avl.
+type avl[K any, V any] struct {
 	root *Node[K, V]
 	size int
+	aug  augmenter[K, V]
+	mods int
+
+	stats    *stats
+	observer Observer
+}
+
+// New returns an empty tree ordered by the natural ordering of K. It is
+// equivalent to the zero Tree.
+func New[K cmp.Ordered, V any]() *Tree[K, V] {
+	return &Tree[K, V]{}
 }
 
 func (tree *Tree[K, V]) Insert(key K, data V) {
 	if tree == nil {
 		panic("bst: called Insert() on a nil tree")
 	}
-	tree.root = insert(tree.root, key, data, &tree.size)
+	tree.root = tree.insert(tree.root, key, data)
+	tree.check()
 }
 
 func (tree *Tree[K, V]) Find(key K) *Node[K, V] {
 	if tree == nil {
 		panic("bst: called Find() on a nil tree")
 	}
+	return tree.lookup(key)
+}
+
+// lookup is the search behind every method that looks up a single key, so
+// that all of them are measured when stats are enabled.
+func (tree *Tree[K, V]) lookup(key K) *Node[K, V] {
+	if tree.stats != nil {
+		return findCounted(tree.root, key, tree.stats)
+	}
 	return find(tree.root, key)
 }
 
+// Get returns the value of key and whether it is present.
+func (tree *Tree[K, V]) Get(key K) (val V, ok bool) {
+	if node := tree.Find(key); node != nil {
+		return node.data, true
+	}
+	return val, false
+}
+
 func (tree *Tree[K, V]) Remove(key K) {
 	if tree == nil {
 		panic("bst: called Remove() on a nil tree")
 	}
-	tree.root = remove(tree.root, key, &tree.size)
+	tree.root = tree.remove(tree.root, key)
+	tree.check()
 }
 
 func (tree *Tree[K, V]) Height() int {
@@ -84,11 +123,12 @@ func (tree *Tree[K, V]) Keys() []K {
 	return keys
 }
 
-type Node[K cmp.Ordered, V any] struct {
+type Node[K any, V any] struct {
 	key    K
 	left   *Node[K, V]
 	right  *Node[K, V]
 	height int
+	size   int
 	data   V
 }
 
@@ -100,100 +140,110 @@ func (node *Node[K, V]) Data() V {
 	return node.data
 }
 
+// Left returns the left child of node, or nil.
+func (node *Node[K, V]) Left() *Node[K, V] {
+	return node.left
+}
+
+// Right returns the right child of node, or nil.
+func (node *Node[K, V]) Right() *Node[K, V] {
+	return node.right
+}
+
 func (node *Node[K, V]) String() string {
 	return fmt.Sprintf("%v", node.key)
 }
 
-func insert[K cmp.Ordered, V any](node *Node[K, V], key K, data V, size *int) *Node[K, V] {
+func (tree *Tree[K, V]) insert(node *Node[K, V], key K, data V) *Node[K, V] {
 	if node == nil || node.height == 0 {
-		*size++
-		return &Node[K, V]{
+		tree.size++
+		tree.mods++
+		tree.stats.inserted()
+		node = &Node[K, V]{
 			key:    key,
 			left:   nil,
 			right:  nil,
 			height: 1,
+			size:   1,
 			data:   data,
 		}
+		if tree.aug != nil {
+			tree.aug.update(node)
+		}
+		return node
 	}
 
-	if key < node.key {
-		node.left = insert(node.left, key, data, size)
-	} else if key > node.key {
-		node.right = insert(node.right, key, data, size)
+	if c := cmp.Compare(key, node.key); c < 0 {
+		node.left = tree.insert(node.left, key, data)
+	} else if c > 0 {
+		node.right = tree.insert(node.right, key, data)
 	} else {
 		return node
 	}
 
-	node.height = 1 + max(height(node.left), height(node.right))
+	tree.update(node)
 	balance := getBalance(node)
 
-	if balance > 1 && key < node.left.key {
-		return rotateRight(node)
+	if balance > 1 && cmp.Compare(key, node.left.key) < 0 {
+		return tree.rotateRight(node, false)
 	}
-	if balance < -1 && key > node.right.key {
-		return rotateLeft(node)
+	if balance < -1 && cmp.Compare(key, node.right.key) > 0 {
+		return tree.rotateLeft(node, false)
 	}
 
-	if balance > 1 && key > node.left.key {
-		node.left = rotateLeft(node.left)
-		return rotateRight(node)
+	if balance > 1 && cmp.Compare(key, node.left.key) > 0 {
+		node.left = tree.rotateLeft(node.left, true)
+		return tree.rotateRight(node, true)
 	}
-	if balance < -1 && key < node.right.key {
-		node.right = rotateRight(node.right)
-		return rotateLeft(node)
+	if balance < -1 && cmp.Compare(key, node.right.key) < 0 {
+		node.right = tree.rotateRight(node.right, true)
+		return tree.rotateLeft(node, true)
 	}
 
 	return node
 }
 
 func find[K cmp.Ordered, V any](node *Node[K, V], key K) *Node[K, V] {
-	if node == nil {
-		return nil
-	}
-
-	if key == node.key {
-		return node
-	}
-
-	if key < node.key {
-		return find(node.left, key)
-	} else {
-		return find(node.right, key)
+	for node != nil {
+		c := cmp.Compare(key, node.key)
+		if c == 0 {
+			return node
+		}
+		if c < 0 {
+			node = node.left
+		} else {
+			node = node.right
+		}
 	}
+	return nil
 }
 
-func remove[K cmp.Ordered, V any](node *Node[K, V], key K, size *int) *Node[K, V] {
+func (tree *Tree[K, V]) remove(node *Node[K, V], key K) *Node[K, V] {
 	if node == nil {
 		return nil
 	}
 
-	if key < node.key {
-		node.left = remove(node.left, key, size)
-	} else if key > node.key {
-		node.right = remove(node.right, key, size)
+	if c := cmp.Compare(key, node.key); c < 0 {
+		node.left = tree.remove(node.left, key)
+	} else if c > 0 {
+		node.right = tree.remove(node.right, key)
 	} else {
 		if node.left == nil || node.right == nil {
-			var temp *Node[K, V]
-
+			// Splice the node out and let its only child, if any, take its place.
 			if node.left != nil {
-				temp = node.left
+				node = node.left
 			} else {
-				temp = node.right
+				node = node.right
 			}
 
-			if temp == nil {
-				temp = node
-				node = nil
-			} else {
-				// TODO: review this, does a shallow copy.
-				*node = *temp
-			}
-
-			*size--
+			tree.size--
+			tree.mods++
+			tree.stats.removed(1)
 		} else {
 			temp := getMinNode(node.right)
 			node.key = temp.key
-			node.right = remove(node.right, temp.key, size)
+			node.data = temp.data
+			node.right = tree.remove(node.right, temp.key)
 		}
 	}
 
@@ -201,43 +251,65 @@ func remove[K cmp.Ordered, V any](node *Node[K, V], key K, size *int) *Node[K, V
 		return nil
 	}
 
-	node.height = 1 + max(height(node.left), height(node.right))
+	return tree.rebalance(node)
+}
+
+// rebalance recomputes the cached fields of node and restores the AVL
+// property if the heights of its subtrees differ by two.
+func (tree *avl[K, V]) rebalance(node *Node[K, V]) *Node[K, V] {
+	tree.update(node)
 	balance := getBalance(node)
 
 	if balance > 1 && getBalance(node.left) >= 0 {
-		return rotateRight(node)
+		return tree.rotateRight(node, false)
 	}
 	if balance > 1 && getBalance(node.left) < 0 {
-		node.left = rotateLeft(node.left)
-		return rotateRight(node)
+		node.left = tree.rotateLeft(node.left, true)
+		return tree.rotateRight(node, true)
 	}
 
 	if balance < -1 && getBalance(node.right) <= 0 {
-		return rotateLeft(node)
+		return tree.rotateLeft(node, false)
 	}
 	if balance < -1 && getBalance(node.right) > 0 {
-		node.right = rotateRight(node.right)
-		return rotateLeft(node)
+		node.right = tree.rotateRight(node.right, true)
+		return tree.rotateLeft(node, true)
 	}
 
 	return node
 }
 
-func height[K cmp.Ordered, V any](node *Node[K, V]) int {
+// update recomputes the height, size and augmented value of node from its children.
+func (tree *avl[K, V]) update(node *Node[K, V]) {
+	node.height = 1 + max(height(node.left), height(node.right))
+	node.size = 1 + getSize(node.left) + getSize(node.right)
+	if tree.aug != nil {
+		tree.aug.update(node)
+	}
+}
+
+func height[K any, V any](node *Node[K, V]) int {
 	if node == nil {
 		return 0
 	}
 	return node.height
 }
 
-func getBalance[K cmp.Ordered, V any](node *Node[K, V]) int {
+func getSize[K any, V any](node *Node[K, V]) int {
+	if node == nil {
+		return 0
+	}
+	return node.size
+}
+
+func getBalance[K any, V any](node *Node[K, V]) int {
 	if node == nil {
 		return 0
 	}
 	return height(node.left) - height(node.right)
 }
 
-func getMinNode[K cmp.Ordered, V any](node *Node[K, V]) *Node[K, V] {
+func getMinNode[K any, V any](node *Node[K, V]) *Node[K, V] {
 	if node == nil {
 		return nil
 	}
@@ -248,28 +320,32 @@ func getMinNode[K cmp.Ordered, V any](node *Node[K, V]) *Node[K, V] {
 	return curr
 }
 
-func rotateRight[K cmp.Ordered, V any](node *Node[K, V]) *Node[K, V] {
+// rotateRight lifts the left child of node. The double flag marks the two
+// rotations that make up a double rotation.
+func (tree *avl[K, V]) rotateRight(node *Node[K, V], double bool) *Node[K, V] {
+	tree.rotated(Right, double)
 	newRoot := node.left
 	t2 := newRoot.right
 
 	newRoot.right = node
 	node.left = t2
 
-	node.height = max(height(node.left), height(node.right)) + 1
-	newRoot.height = max(height(newRoot.left), height(newRoot.right)) + 1
+	tree.update(node)
+	tree.update(newRoot)
 
 	return newRoot
 }
 
-func rotateLeft[K cmp.Ordered, V any](node *Node[K, V]) *Node[K, V] {
+func (tree *avl[K, V]) rotateLeft(node *Node[K, V], double bool) *Node[K, V] {
+	tree.rotated(Left, double)
 	newRoot := node.right
 	t2 := newRoot.left
 
 	newRoot.left = node
 	node.right = t2
 
-	node.height = max(height(node.left), height(node.right)) + 1
-	newRoot.height = max(height(newRoot.left), height(newRoot.right)) + 1
+	tree.update(node)
+	tree.update(newRoot)
 
 	return newRoot
 }
@@ -0,0 +1,1770 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestTree(keys ...int) *Tree[int, string] {
+	tree := &Tree[int, string]{}
+	for _, k := range keys {
+		tree.Insert(k, strconv.Itoa(k))
+	}
+	return tree
+}
+
+func TestTreeAll(t *testing.T) {
+	tree := newTestTree(50, 20, 80, 10, 30, 70, 90, 60, 40)
+
+	expected := []int{10, 20, 30, 40, 50, 60, 70, 80, 90}
+	i := 0
+	for k, v := range tree.All() {
+		if k != expected[i] {
+			t.Fatalf("Expected key at idx %d to be %d, got %d instead", i, expected[i], k)
+		}
+		if v != strconv.Itoa(k) {
+			t.Fatalf("Expected value for key %d to be %q, got %q instead", k, strconv.Itoa(k), v)
+		}
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("Expected %d pairs, got %d instead", len(expected), i)
+	}
+}
+
+func TestTreeAllBreak(t *testing.T) {
+	tree := newTestTree(5, 3, 8, 1, 4)
+
+	var got []int
+	for k := range tree.All() {
+		if k > 3 {
+			break
+		}
+		got = append(got, k)
+	}
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("Expected [1 3], got %v instead", got)
+	}
+}
+
+func TestTreeBackward(t *testing.T) {
+	tree := newTestTree(5, 3, 8, 1, 4, 9, 7)
+
+	expected := []int{9, 8, 7, 5, 4, 3, 1}
+	i := 0
+	for k := range tree.Backward() {
+		if k != expected[i] {
+			t.Fatalf("Expected key at idx %d to be %d, got %d instead", i, expected[i], k)
+		}
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("Expected %d keys, got %d instead", len(expected), i)
+	}
+}
+
+func TestTreeRange(t *testing.T) {
+	tree := &Tree[int, string]{}
+	for i := 0; i < 100; i += 2 {
+		tree.Insert(i, "")
+	}
+
+	tests := []struct {
+		lo, hi   int
+		expected []int
+	}{
+		{lo: 10, hi: 16, expected: []int{10, 12, 14, 16}},
+		{lo: 11, hi: 17, expected: []int{12, 14, 16}},
+		{lo: -5, hi: 3, expected: []int{0, 2}},
+		{lo: 95, hi: 200, expected: []int{96, 98}},
+		{lo: 41, hi: 41, expected: nil},
+		{lo: 20, hi: 10, expected: nil},
+	}
+
+	for _, tt := range tests {
+		var got []int
+		for k := range tree.Range(tt.lo, tt.hi) {
+			got = append(got, k)
+		}
+		if len(got) != len(tt.expected) {
+			t.Fatalf("Range(%d, %d): expected %v, got %v instead", tt.lo, tt.hi, tt.expected, got)
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Fatalf("Range(%d, %d): expected %v, got %v instead", tt.lo, tt.hi, tt.expected, got)
+			}
+		}
+	}
+}
+
+func TestTreeIterEmpty(t *testing.T) {
+	var tree *Tree[int, string]
+
+	for range tree.All() {
+		t.Fatal("Expected no pairs from a nil tree")
+	}
+
+	tree = &Tree[int, string]{}
+	for range tree.Range(0, 10) {
+		t.Fatal("Expected no pairs from an empty tree")
+	}
+}
+
+func TestTreeRank(t *testing.T) {
+	tree := &Tree[int, string]{}
+	for i := 1; i <= 100; i++ {
+		tree.Insert(i*10, "")
+	}
+	for i := 1; i <= 50; i++ {
+		tree.Remove(i * 20)
+	}
+
+	keys := tree.Keys()
+	for i, k := range keys {
+		if r := tree.Rank(k); r != i {
+			t.Fatalf("Expected Rank(%d) to be %d, got %d instead", k, i, r)
+		}
+		if r := tree.Rank(k + 1); r != i+1 {
+			t.Fatalf("Expected Rank(%d) to be %d, got %d instead", k+1, i+1, r)
+		}
+		if n := tree.Select(i); n.Key() != k {
+			t.Fatalf("Expected Select(%d) to be %d, got %d instead", i, k, n.Key())
+		}
+	}
+	if r := tree.Rank(0); r != 0 {
+		t.Errorf("Expected Rank(0) to be 0, got %d instead", r)
+	}
+}
+
+func TestTreeSelectInvalidIdx(t *testing.T) {
+	tree := newTestTree(1, 2, 3)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected to recover from panic after tree.Select(3), got nil instead")
+		}
+	}()
+
+	tree.Select(3)
+}
+
+func TestTreeCountRange(t *testing.T) {
+	tree := newTestTree(10, 20, 30, 40, 50, 60, 70)
+
+	tests := []struct {
+		lo, hi, expected int
+	}{
+		{lo: 20, hi: 50, expected: 4},
+		{lo: 21, hi: 49, expected: 2},
+		{lo: 0, hi: 100, expected: 7},
+		{lo: 70, hi: 70, expected: 1},
+		{lo: 71, hi: 80, expected: 0},
+		{lo: 50, hi: 20, expected: 0},
+	}
+
+	for _, tt := range tests {
+		if n := tree.CountRange(tt.lo, tt.hi); n != tt.expected {
+			t.Errorf("Expected CountRange(%d, %d) to be %d, got %d instead", tt.lo, tt.hi, tt.expected, n)
+		}
+	}
+}
+
+func TestTreeFloorCeiling(t *testing.T) {
+	tree := newTestTree(10, 20, 30, 40, 50)
+
+	tests := []struct {
+		key                                    int
+		floor, ceiling, predecessor, successor int
+	}{
+		{key: 30, floor: 30, ceiling: 30, predecessor: 20, successor: 40},
+		{key: 35, floor: 30, ceiling: 40, predecessor: 30, successor: 40},
+		{key: 10, floor: 10, ceiling: 10, predecessor: -1, successor: 20},
+		{key: 5, floor: -1, ceiling: 10, predecessor: -1, successor: 10},
+		{key: 50, floor: 50, ceiling: 50, predecessor: 40, successor: -1},
+		{key: 55, floor: 50, ceiling: -1, predecessor: 50, successor: -1},
+	}
+
+	keyOf := func(n *Node[int, string]) int {
+		if n == nil {
+			return -1
+		}
+		return n.Key()
+	}
+
+	for _, tt := range tests {
+		if k := keyOf(tree.Floor(tt.key)); k != tt.floor {
+			t.Errorf("Expected Floor(%d) to be %d, got %d instead", tt.key, tt.floor, k)
+		}
+		if k := keyOf(tree.Ceiling(tt.key)); k != tt.ceiling {
+			t.Errorf("Expected Ceiling(%d) to be %d, got %d instead", tt.key, tt.ceiling, k)
+		}
+		if k := keyOf(tree.Predecessor(tt.key)); k != tt.predecessor {
+			t.Errorf("Expected Predecessor(%d) to be %d, got %d instead", tt.key, tt.predecessor, k)
+		}
+		if k := keyOf(tree.Successor(tt.key)); k != tt.successor {
+			t.Errorf("Expected Successor(%d) to be %d, got %d instead", tt.key, tt.successor, k)
+		}
+	}
+}
+
+func TestTreeMinMax(t *testing.T) {
+	tree := &Tree[int, string]{}
+
+	if tree.Min() != nil || tree.Max() != nil {
+		t.Fatal("Expected Min() and Max() of an empty tree to be nil")
+	}
+
+	tree = newTestTree(42, 7, 99, 13, 64)
+
+	if k := tree.Min().Key(); k != 7 {
+		t.Errorf("Expected Min() to be %d, got %d instead", 7, k)
+	}
+	if k := tree.Max().Key(); k != 99 {
+		t.Errorf("Expected Max() to be %d, got %d instead", 99, k)
+	}
+}
+
+func TestTreePut(t *testing.T) {
+	tree := &Tree[int, string]{}
+
+	if old, replaced := tree.Put(1, "a"); replaced || old != "" {
+		t.Fatalf("Expected Put(1, \"a\") to insert, got (%q, %t) instead", old, replaced)
+	}
+	if old, replaced := tree.Put(1, "b"); !replaced || old != "a" {
+		t.Fatalf("Expected Put(1, \"b\") to replace \"a\", got (%q, %t) instead", old, replaced)
+	}
+	if n := tree.Size(); n != 1 {
+		t.Errorf("Expected size to be 1, got %d instead", n)
+	}
+	if v := tree.Find(1).Data(); v != "b" {
+		t.Errorf("Expected value to be \"b\", got %q instead", v)
+	}
+}
+
+func TestTreeGetOrInsert(t *testing.T) {
+	tree := &Tree[int, string]{}
+
+	if v, loaded := tree.GetOrInsert(1, "a"); loaded || v != "a" {
+		t.Fatalf("Expected GetOrInsert(1, \"a\") to insert, got (%q, %t) instead", v, loaded)
+	}
+	if v, loaded := tree.GetOrInsert(1, "b"); !loaded || v != "a" {
+		t.Fatalf("Expected GetOrInsert(1, \"b\") to load \"a\", got (%q, %t) instead", v, loaded)
+	}
+}
+
+func TestTreeUpdate(t *testing.T) {
+	tree := &Tree[string, int]{}
+	incr := func(old int, exists bool) (int, bool) {
+		return old + 1, true
+	}
+
+	tree.Update("a", incr)
+	tree.Update("a", incr)
+	tree.Update("b", incr)
+
+	if v := tree.Find("a").Data(); v != 2 {
+		t.Errorf("Expected value of \"a\" to be 2, got %d instead", v)
+	}
+
+	tree.Update("a", func(old int, exists bool) (int, bool) {
+		return 0, false
+	})
+	tree.Update("c", func(old int, exists bool) (int, bool) {
+		if exists {
+			t.Error("Expected \"c\" to be missing")
+		}
+		return 0, false
+	})
+
+	if tree.Find("a") != nil {
+		t.Error("Expected \"a\" to be removed")
+	}
+	if tree.Find("c") != nil {
+		t.Error("Expected \"c\" not to be inserted")
+	}
+	if n := tree.Size(); n != 1 {
+		t.Errorf("Expected size to be 1, got %d instead", n)
+	}
+}
+
+func TestTreeDelete(t *testing.T) {
+	tree := newTestTree(50, 20, 80, 10, 30, 70, 90)
+
+	if v, ok := tree.Delete(20); !ok || v != "20" {
+		t.Fatalf("Expected Delete(20) to return (\"20\", true), got (%q, %t) instead", v, ok)
+	}
+	if _, ok := tree.Delete(20); ok {
+		t.Fatal("Expected second Delete(20) to report a missing key")
+	}
+	if n := tree.Size(); n != 6 {
+		t.Errorf("Expected size to be 6, got %d instead", n)
+	}
+	for k, v := range tree.All() {
+		if v != strconv.Itoa(k) {
+			t.Errorf("Expected value for key %d to be %q, got %q instead", k, strconv.Itoa(k), v)
+		}
+	}
+}
+
+func TestTreeNewFunc(t *testing.T) {
+	type point struct{ x, y int }
+
+	tree := NewFunc[point, string](func(a, b point) int {
+		if c := cmp.Compare(a.x, b.x); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.y, b.y)
+	})
+
+	tree.Insert(point{2, 1}, "c")
+	tree.Insert(point{1, 5}, "b")
+	tree.Insert(point{1, 2}, "a")
+	tree.Insert(point{1, 2}, "dup")
+
+	if n := tree.Size(); n != 3 {
+		t.Fatalf("Expected size to be 3, got %d instead", n)
+	}
+	expected := []string{"a", "b", "c"}
+	i := 0
+	for _, v := range tree.All() {
+		if v != expected[i] {
+			t.Fatalf("Expected value at idx %d to be %q, got %q instead", i, expected[i], v)
+		}
+		i++
+	}
+	if n := tree.Find(point{1, 5}); n == nil || n.Data() != "b" {
+		t.Error("Expected to find point{1, 5}")
+	}
+
+	tree.Remove(point{1, 2})
+	if tree.Find(point{1, 2}) != nil {
+		t.Error("Expected point{1, 2} to be removed")
+	}
+}
+
+func TestTreeNewFuncNil(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected to recover from panic after NewFunc(nil), got nil instead")
+		}
+	}()
+
+	NewFunc[int, int](nil)
+}
+
+func TestTreeNewFuncAugmented(t *testing.T) {
+	type summed struct{ val, sum int }
+
+	tree := NewFuncAugmented(cmp.Compare[int], func(key int, data *summed, left, right *summed) {
+		data.sum = data.val
+		for _, child := range []*summed{left, right} {
+			if child != nil {
+				data.sum += child.sum
+			}
+		}
+	})
+
+	var check func(node *Node[int, summed]) int
+	check = func(node *Node[int, summed]) int {
+		if node == nil {
+			return 0
+		}
+		sum := node.Data().val + check(node.Left()) + check(node.Right())
+		if node.Data().sum != sum {
+			t.Fatalf("Expected node %v to have sum %d, got %d instead", node, sum, node.Data().sum)
+		}
+		return sum
+	}
+
+	ref := make(map[int]int)
+	for i := range 2000 {
+		k := rand.IntN(200)
+		switch rand.IntN(3) {
+		case 0:
+			tree.Insert(k, summed{val: i})
+			if _, ok := ref[k]; !ok {
+				ref[k] = i
+			}
+		case 1:
+			old, replaced := tree.Put(k, summed{val: i})
+			if prev, ok := ref[k]; ok != replaced || (ok && old.val != prev) {
+				t.Fatalf("Expected Put(%d) to return (%d, %v), got (%d, %v) instead", k, prev, ok, old.val, replaced)
+			}
+			ref[k] = i
+		case 2:
+			tree.Remove(k)
+			delete(ref, k)
+		}
+	}
+
+	total := 0
+	for _, v := range ref {
+		total += v
+	}
+	if got := check(tree.Root()); got != total {
+		t.Errorf("Expected the root sum to be %d, got %d instead", total, got)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTreeNaturalOrder(t *testing.T) {
+	type score int
+
+	tree := &Tree[score, string]{}
+	tree.Insert(30, "")
+	tree.Insert(-10, "")
+	tree.Insert(20, "")
+
+	keys := tree.Keys()
+	if len(keys) != 3 || keys[0] != -10 || keys[1] != 20 || keys[2] != 30 {
+		t.Errorf("Expected keys [-10 20 30], got %v instead", keys)
+	}
+
+	str := New[string, int]()
+	str.Insert("b", 2)
+	str.Insert("a", 1)
+	if n := str.Min(); n.Key() != "a" {
+		t.Errorf("Expected Min() to be \"a\", got %q instead", n.Key())
+	}
+}
+
+func TestPersistent(t *testing.T) {
+	var versions []Persistent[int, int]
+
+	p := NewPersistent[int, int]()
+	for i := 0; i < 64; i++ {
+		p = p.Insert((i*37)%64, i)
+		versions = append(versions, p)
+	}
+	for i := 0; i < 64; i += 2 {
+		p = p.Remove(i)
+		versions = append(versions, p)
+	}
+
+	for i, v := range versions {
+		expected := i + 1
+		if i >= 64 {
+			expected = 64 - (i - 63)
+		}
+		if n := v.Size(); n != expected {
+			t.Fatalf("Expected version %d to have size %d, got %d instead", i, expected, n)
+		}
+		checkBalanced(t, v.root)
+	}
+
+	if versions[63].Find(10) == nil {
+		t.Error("Expected old version to still contain 10")
+	}
+	if p.Find(10) != nil {
+		t.Error("Expected latest version not to contain 10")
+	}
+	if p.Find(11) == nil {
+		t.Error("Expected latest version to contain 11")
+	}
+}
+
+func TestPersistentPut(t *testing.T) {
+	a := NewPersistent[string, int]().Put("x", 1)
+	b := a.Put("x", 2)
+	c := b.Insert("x", 3)
+
+	if v := a.Find("x").Data(); v != 1 {
+		t.Errorf("Expected a[x] to be 1, got %d instead", v)
+	}
+	if v := b.Find("x").Data(); v != 2 {
+		t.Errorf("Expected b[x] to be 2, got %d instead", v)
+	}
+	if v := c.Find("x").Data(); v != 2 {
+		t.Errorf("Expected c[x] to be 2, got %d instead", v)
+	}
+	if c.root != b.root {
+		t.Error("Expected Insert() of an existing key to share the root")
+	}
+}
+
+func checkBalanced[K any, V any](t *testing.T, node *Node[K, V]) int {
+	t.Helper()
+	if node == nil {
+		return 0
+	}
+	l, r := checkBalanced(t, node.left), checkBalanced(t, node.right)
+	if l-r > 1 || r-l > 1 {
+		t.Fatalf("Node %v is unbalanced: left height %d, right height %d", node, l, r)
+	}
+	if node.height != max(l, r)+1 {
+		t.Fatalf("Node %v has height %d, expected %d", node, node.height, max(l, r)+1)
+	}
+	if size := 1 + getSize(node.left) + getSize(node.right); node.size != size {
+		t.Fatalf("Node %v has size %d, expected %d", node, node.size, size)
+	}
+	return node.height
+}
+
+func TestTreeSplit(t *testing.T) {
+	tree := &Tree[int, string]{}
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, strconv.Itoa(i))
+	}
+
+	left, right := tree.Split(40)
+
+	if tree.Size() != 0 {
+		t.Errorf("Expected split tree to be empty, got size %d instead", tree.Size())
+	}
+	if n := left.Size(); n != 40 {
+		t.Errorf("Expected left size to be 40, got %d instead", n)
+	}
+	if n := right.Size(); n != 60 {
+		t.Errorf("Expected right size to be 60, got %d instead", n)
+	}
+	if k := left.Max().Key(); k != 39 {
+		t.Errorf("Expected left max to be 39, got %d instead", k)
+	}
+	if k := right.Min().Key(); k != 40 {
+		t.Errorf("Expected right min to be 40, got %d instead", k)
+	}
+	checkBalanced(t, left.root)
+	checkBalanced(t, right.root)
+
+	joined := Join(left, right)
+	if n := joined.Size(); n != 100 {
+		t.Errorf("Expected joined size to be 100, got %d instead", n)
+	}
+	checkBalanced(t, joined.root)
+	for i, k := range joined.Keys() {
+		if k != i {
+			t.Fatalf("Expected key at idx %d to be %d, got %d instead", i, i, k)
+		}
+	}
+}
+
+func TestTreeJoinUneven(t *testing.T) {
+	left, right := &Tree[int, int]{}, &Tree[int, int]{}
+	for i := 0; i < 3; i++ {
+		left.Insert(i, i)
+	}
+	for i := 10; i < 500; i++ {
+		right.Insert(i, i)
+	}
+
+	joined := Join(left, right)
+	checkBalanced(t, joined.root)
+	if n := joined.Size(); n != 493 {
+		t.Errorf("Expected joined size to be 493, got %d instead", n)
+	}
+	if left.Size() != 0 || right.Size() != 0 {
+		t.Error("Expected joined trees to be empty")
+	}
+}
+
+func TestTreeJoinOverlapping(t *testing.T) {
+	left, right := newTestTree(1, 5), newTestTree(3, 7)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected to recover from panic after joining overlapping trees, got nil instead")
+		}
+	}()
+
+	Join(left, right)
+}
+
+func TestTreeSetOperations(t *testing.T) {
+	build := func() (*Tree[int, string], *Tree[int, string]) {
+		a, b := &Tree[int, string]{}, &Tree[int, string]{}
+		for i := 0; i < 300; i += 2 {
+			a.Insert(i, "a")
+		}
+		for i := 0; i < 300; i += 3 {
+			b.Insert(i, "b")
+		}
+		return a, b
+	}
+
+	tests := []struct {
+		name  string
+		op    func(a, b *Tree[int, string])
+		keep  func(k int) bool
+		value func(k int) string
+	}{
+		{
+			name:  "Union",
+			op:    (*Tree[int, string]).Union,
+			keep:  func(k int) bool { return k%2 == 0 || k%3 == 0 },
+			value: func(k int) string { return map[bool]string{true: "b", false: "a"}[k%3 == 0] },
+		},
+		{
+			name:  "Intersection",
+			op:    (*Tree[int, string]).Intersection,
+			keep:  func(k int) bool { return k%2 == 0 && k%3 == 0 },
+			value: func(k int) string { return "a" },
+		},
+		{
+			name:  "Difference",
+			op:    (*Tree[int, string]).Difference,
+			keep:  func(k int) bool { return k%2 == 0 && k%3 != 0 },
+			value: func(k int) string { return "a" },
+		},
+	}
+
+	for _, tt := range tests {
+		a, b := build()
+		tt.op(a, b)
+
+		if b.Size() != 0 {
+			t.Errorf("%s: expected other tree to be empty, got size %d instead", tt.name, b.Size())
+		}
+		checkBalanced(t, a.root)
+
+		var expected []int
+		for k := 0; k < 300; k++ {
+			if tt.keep(k) {
+				expected = append(expected, k)
+			}
+		}
+		if n := a.Size(); n != len(expected) {
+			t.Fatalf("%s: expected size %d, got %d instead", tt.name, len(expected), n)
+		}
+		i := 0
+		for k, v := range a.All() {
+			if k != expected[i] {
+				t.Fatalf("%s: expected key at idx %d to be %d, got %d instead", tt.name, i, expected[i], k)
+			}
+			if v != tt.value(k) {
+				t.Fatalf("%s: expected value of %d to be %q, got %q instead", tt.name, k, tt.value(k), v)
+			}
+			i++
+		}
+	}
+}
+
+func TestTreeSetOperationsSelf(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(a, b *Tree[int, string])
+		size int
+	}{
+		{name: "Union", op: (*Tree[int, string]).Union, size: 100},
+		{name: "Intersection", op: (*Tree[int, string]).Intersection, size: 100},
+		{name: "Difference", op: (*Tree[int, string]).Difference, size: 0},
+	}
+
+	for _, tt := range tests {
+		tree := &Tree[int, string]{}
+		for i := 0; i < 100; i++ {
+			tree.Insert(i, strconv.Itoa(i))
+		}
+		tt.op(tree, tree)
+
+		if n := tree.Size(); n != tt.size {
+			t.Errorf("%s: expected size %d, got %d instead", tt.name, tt.size, n)
+		}
+		if n := len(tree.Keys()); n != tt.size {
+			t.Errorf("%s: expected %d keys, got %d instead", tt.name, tt.size, n)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestTreeFromSorted(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 7, 8, 100, 1023} {
+		keys := make([]int, n)
+		vals := make([]string, n)
+		for i := range keys {
+			keys[i] = i * 3
+			vals[i] = strconv.Itoa(i * 3)
+		}
+
+		tree := FromSorted(keys, vals)
+		checkBalanced(t, tree.root)
+
+		if s := tree.Size(); s != n {
+			t.Fatalf("Expected size to be %d, got %d instead", n, s)
+		}
+		i := 0
+		for k, v := range tree.All() {
+			if k != keys[i] || v != vals[i] {
+				t.Fatalf("Expected pair at idx %d to be (%d, %q), got (%d, %q) instead", i, keys[i], vals[i], k, v)
+			}
+			i++
+		}
+
+		tree.Insert(-1, "")
+		tree.Remove(0)
+		checkBalanced(t, tree.root)
+	}
+}
+
+func TestTreeFromSortedUnsorted(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected to recover from panic after FromSorted() with unsorted keys, got nil instead")
+		}
+	}()
+
+	FromSorted([]int{1, 3, 2}, []int{1, 2, 3})
+}
+
+func TestTreeFromSeq(t *testing.T) {
+	src := newTestTree(5, 1, 9, 3, 7)
+
+	tree := FromSeq(src.All())
+	checkBalanced(t, tree.root)
+
+	if n := tree.Size(); n != 5 {
+		t.Fatalf("Expected size to be 5, got %d instead", n)
+	}
+	if n := tree.Find(7); n == nil || n.Data() != "7" {
+		t.Error("Expected to find key 7 with value \"7\"")
+	}
+}
+
+func TestTreeValidate(t *testing.T) {
+	tree := &Tree[int, string]{}
+	for i := 0; i < 50; i++ {
+		tree.Insert((i*7)%50, "")
+	}
+	for i := 0; i < 50; i += 3 {
+		tree.Remove(i)
+	}
+
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("Expected valid tree, got %v instead", err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(tree *Tree[int, string]) func()
+	}{
+		{
+			name: "height",
+			corrupt: func(tree *Tree[int, string]) func() {
+				n := tree.root.left
+				n.height++
+				return func() { n.height-- }
+			},
+		},
+		{
+			name: "size",
+			corrupt: func(tree *Tree[int, string]) func() {
+				n := tree.root.right
+				n.size--
+				return func() { n.size++ }
+			},
+		},
+		{
+			name: "tree size",
+			corrupt: func(tree *Tree[int, string]) func() {
+				tree.size++
+				return func() { tree.size-- }
+			},
+		},
+		{
+			name: "order",
+			corrupt: func(tree *Tree[int, string]) func() {
+				n := getMaxNode(tree.root.left)
+				old := n.key
+				n.key = tree.root.key + 1
+				return func() { n.key = old }
+			},
+		},
+		{
+			name: "balance",
+			corrupt: func(tree *Tree[int, string]) func() {
+				n := tree.root
+				left := n.left
+				n.left = nil
+				n.height = height(n.right) + 1
+				n.size = getSize(n.right) + 1
+				return func() {
+					n.left = left
+					n.height = 1 + max(height(n.left), height(n.right))
+					n.size = 1 + getSize(n.left) + getSize(n.right)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		restore := tt.corrupt(tree)
+		if err := tree.Validate(); err == nil {
+			t.Errorf("Expected Validate() to detect corrupted %s", tt.name)
+		}
+		restore()
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("Expected valid tree after restoring %s, got %v instead", tt.name, err)
+		}
+	}
+}
+
+func TestTreeJSON(t *testing.T) {
+	tree := newTestTree(3, 1, 2)
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+	expected := `[{"key":1,"value":"1"},{"key":2,"value":"2"},{"key":3,"value":"3"}]`
+	if string(data) != expected {
+		t.Fatalf("Expected %s, got %s instead", expected, data)
+	}
+
+	decoded := &Tree[int, string]{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Fatalf("Expected valid tree, got %v instead", err)
+	}
+	if n := decoded.Size(); n != 3 {
+		t.Errorf("Expected size to be 3, got %d instead", n)
+	}
+
+	unsorted := `[{"key":5,"value":"a"},{"key":1,"value":"b"},{"key":5,"value":"c"}]`
+	if err := json.Unmarshal([]byte(unsorted), decoded); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+	if n := decoded.Size(); n != 2 {
+		t.Errorf("Expected size to be 2, got %d instead", n)
+	}
+	if v := decoded.Find(5).Data(); v != "c" {
+		t.Errorf("Expected value of 5 to be \"c\", got %q instead", v)
+	}
+}
+
+func TestTreeBinary(t *testing.T) {
+	tree := &Tree[string, float64]{}
+	for i := 0; i < 100; i++ {
+		tree.Insert("k"+strconv.Itoa(i), float64(i)/2)
+	}
+
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	decoded := &Tree[string, float64]{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Fatalf("Expected valid tree, got %v instead", err)
+	}
+	if n := decoded.Size(); n != 100 {
+		t.Fatalf("Expected size to be 100, got %d instead", n)
+	}
+	for k, v := range tree.All() {
+		if n := decoded.Find(k); n == nil || n.Data() != v {
+			t.Fatalf("Expected decoded tree to contain (%q, %v)", k, v)
+		}
+	}
+
+	data[0] = binaryVersion + 1
+	if err := decoded.UnmarshalBinary(data); err == nil {
+		t.Error("Expected error for unsupported version, got nil instead")
+	}
+}
+
+func TestAugmentedSum(t *testing.T) {
+	tree := NewAugmented(func(a, b int) int { return a + b }, func(k, v int) int { return v })
+	values := map[int]int{}
+
+	for i := 0; i < 300; i++ {
+		k := (i * 71) % 257
+		tree.Put(k, i)
+		values[k] = i
+	}
+	for k := 0; k < 257; k += 5 {
+		tree.Remove(k)
+		delete(values, k)
+	}
+	for k := 1; k < 257; k += 7 {
+		tree.Update(k, func(old int, exists bool) (int, bool) {
+			values[k] = old * 2
+			return old * 2, true
+		})
+	}
+
+	for lo := -10; lo < 270; lo += 13 {
+		for hi := lo; hi < 270; hi += 29 {
+			expected, count := 0, 0
+			for k, v := range values {
+				if k >= lo && k <= hi {
+					expected += v
+					count++
+				}
+			}
+			sum, ok := tree.Aggregate(lo, hi)
+			if ok != (count > 0) || sum != expected {
+				t.Fatalf("Expected Aggregate(%d, %d) to be (%d, %t), got (%d, %t) instead", lo, hi, expected, count > 0, sum, ok)
+			}
+		}
+	}
+}
+
+func TestAugmentedOrder(t *testing.T) {
+	tree := NewAugmented(func(a, b string) string { return a + b }, func(k int, v string) string { return v })
+	for _, s := range []string{"d", "a", "e", "c", "b", "f"} {
+		tree.Insert(int(s[0]-'a'), s)
+	}
+
+	if s, _ := tree.Aggregate(0, 5); s != "abcdef" {
+		t.Errorf("Expected Aggregate(0, 5) to be \"abcdef\", got %q instead", s)
+	}
+	if s, _ := tree.Aggregate(1, 3); s != "bcd" {
+		t.Errorf("Expected Aggregate(1, 3) to be \"bcd\", got %q instead", s)
+	}
+
+	left, right := tree.Split(3)
+	if ag, _ := left.tree.aug.(*aggregator[int, string, string]); ag == nil {
+		t.Fatal("Expected split trees to keep the augmentation")
+	}
+	if agg := right.tree.root.data.agg; agg != "def" {
+		t.Errorf("Expected right aggregate to be \"def\", got %q instead", agg)
+	}
+	if agg := left.tree.root.data.agg; agg != "abc" {
+		t.Errorf("Expected left aggregate to be \"abc\", got %q instead", agg)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	m := Multi[int, string]{}
+
+	m.Add(10, "a")
+	m.Add(5, "b")
+	m.Add(10, "c")
+	m.Add(10, "d")
+	m.Add(20, "e")
+
+	if n := m.Size(); n != 5 {
+		t.Fatalf("Expected size to be 5, got %d instead", n)
+	}
+	if n := m.Count(10); n != 3 {
+		t.Errorf("Expected Count(10) to be 3, got %d instead", n)
+	}
+
+	var got []string
+	for v := range m.GetAll(10) {
+		got = append(got, v)
+	}
+	if len(got) != 3 || got[0] != "a" || got[1] != "c" || got[2] != "d" {
+		t.Errorf("Expected GetAll(10) to return [a c d], got %v instead", got)
+	}
+
+	got = nil
+	for _, v := range m.All() {
+		got = append(got, v)
+	}
+	if len(got) != 5 || got[0] != "b" || got[1] != "a" || got[4] != "e" {
+		t.Errorf("Expected All() to return [b a c d e], got %v instead", got)
+	}
+
+	if r := m.Rank(20); r != 4 {
+		t.Errorf("Expected Rank(20) to be 4, got %d instead", r)
+	}
+	if k, v := m.Select(3); k != 10 || v != "d" {
+		t.Errorf("Expected Select(3) to be (10, \"d\"), got (%d, %q) instead", k, v)
+	}
+	if n := m.CountRange(6, 20); n != 4 {
+		t.Errorf("Expected CountRange(6, 20) to be 4, got %d instead", n)
+	}
+
+	if v, ok := m.RemoveOne(10); !ok || v != "a" {
+		t.Errorf("Expected RemoveOne(10) to return (\"a\", true), got (%q, %t) instead", v, ok)
+	}
+	if n := m.Count(10); n != 2 {
+		t.Errorf("Expected Count(10) to be 2, got %d instead", n)
+	}
+	if n := m.RemoveAll(10); n != 2 {
+		t.Errorf("Expected RemoveAll(10) to be 2, got %d instead", n)
+	}
+	if _, ok := m.RemoveOne(10); ok {
+		t.Error("Expected RemoveOne(10) on a missing key to fail")
+	}
+	if v, ok := m.RemoveOne(5); !ok || v != "b" {
+		t.Errorf("Expected RemoveOne(5) to return (\"b\", true), got (%q, %t) instead", v, ok)
+	}
+	if n := m.Size(); n != 1 {
+		t.Errorf("Expected size to be 1, got %d instead", n)
+	}
+	if keys := m.Keys(); len(keys) != 1 || keys[0] != 20 {
+		t.Errorf("Expected keys [20], got %v instead", keys)
+	}
+}
+
+func TestMultiRank(t *testing.T) {
+	m := NewMulti[int, int]()
+	var pairs [][2]int
+
+	for k := 0; k < 50; k++ {
+		for j := 0; j <= k%4; j++ {
+			m.Add(k, j)
+			pairs = append(pairs, [2]int{k, j})
+		}
+	}
+
+	for i, p := range pairs {
+		if k, v := m.Select(i); k != p[0] || v != p[1] {
+			t.Fatalf("Expected Select(%d) to be %v, got (%d, %d) instead", i, p, k, v)
+		}
+	}
+	for i, p := range pairs {
+		if i > 0 && pairs[i-1][0] == p[0] {
+			continue
+		}
+		if r := m.Rank(p[0]); r != i {
+			t.Fatalf("Expected Rank(%d) to be %d, got %d instead", p[0], i, r)
+		}
+	}
+	if err := m.tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	tree := newTestTree(10, 20, 30, 40, 50)
+	c := tree.Cursor()
+
+	var got []int
+	for ok := c.First(); ok; ok = c.Next() {
+		got = append(got, c.Key())
+	}
+	if len(got) != 5 || got[0] != 10 || got[4] != 50 {
+		t.Errorf("Expected forward walk [10 20 30 40 50], got %v instead", got)
+	}
+
+	got = nil
+	for ok := c.Last(); ok; ok = c.Prev() {
+		got = append(got, c.Key())
+	}
+	if len(got) != 5 || got[0] != 50 || got[4] != 10 {
+		t.Errorf("Expected backward walk [50 40 30 20 10], got %v instead", got)
+	}
+
+	if !c.Seek(25) || c.Key() != 30 || c.Value() != "30" {
+		t.Error("Expected Seek(25) to position the cursor at 30")
+	}
+	if c.Seek(55) {
+		t.Error("Expected Seek(55) to report no entry")
+	}
+}
+
+func TestCursorRandom(t *testing.T) {
+	tree := &Tree[int, int]{}
+	for _, k := range rand.Perm(500) {
+		tree.Insert(k*2, k)
+	}
+	keys := tree.Keys()
+	c := tree.Cursor()
+
+	var got []int
+	for ok := c.First(); ok; ok = c.Next() {
+		got = append(got, c.Key())
+	}
+	if !slices.Equal(got, keys) {
+		t.Fatalf("Expected the forward walk to match Keys()")
+	}
+	got = got[:0]
+	for ok := c.Last(); ok; ok = c.Prev() {
+		got = append(got, c.Key())
+	}
+	slices.Reverse(got)
+	if !slices.Equal(got, keys) {
+		t.Fatalf("Expected the backward walk to match Keys()")
+	}
+
+	for range 200 {
+		k := rand.IntN(1010) - 5
+		node := tree.Ceiling(k)
+		if ok := c.Seek(k); ok != (node != nil) || (ok && c.Key() != node.Key()) {
+			t.Fatalf("Expected Seek(%d) to agree with Ceiling(%d) = %v", k, k, node)
+		}
+		if !c.Valid() {
+			continue
+		}
+		// Stepping back and forth returns to the same key.
+		key := c.Key()
+		if c.Prev() {
+			c.Next()
+		} else {
+			c.First()
+		}
+		if c.Key() != key {
+			t.Fatalf("Expected to return to %d, got %d instead", key, c.Key())
+		}
+	}
+
+	// Delete every other key through the cursor and check the rest.
+	for ok := c.First(); ok; {
+		ok = c.Delete() && c.Next()
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	want := make([]int, 0, len(keys)/2)
+	for i := 1; i < len(keys); i += 2 {
+		want = append(want, keys[i])
+	}
+	if !slices.Equal(tree.Keys(), want) {
+		t.Errorf("Expected every other key to remain, got %v instead", tree.Keys())
+	}
+}
+
+func TestCursorDelete(t *testing.T) {
+	tree := &Tree[int, int]{}
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, i)
+	}
+
+	c := tree.Cursor()
+	for ok := c.First(); ok; {
+		if c.Key()%3 == 0 {
+			ok = c.Delete()
+		} else {
+			ok = c.Next()
+		}
+	}
+
+	if n := tree.Size(); n != 66 {
+		t.Fatalf("Expected size to be 66, got %d instead", n)
+	}
+	for k := range tree.All() {
+		if k%3 == 0 {
+			t.Fatalf("Expected key %d to be deleted", k)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCursorConcurrentModification(t *testing.T) {
+	tree := newTestTree(1, 2, 3)
+	c := tree.Cursor()
+	c.First()
+
+	tree.Insert(4, "4")
+
+	if c.Valid() {
+		t.Error("Expected cursor to be invalidated by Insert()")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected to recover from panic after Next() on an invalidated cursor, got nil instead")
+		}
+	}()
+
+	c.Next()
+}
+
+func TestTreeFormat(t *testing.T) {
+	tree := newTestTree(50, 20, 80, 10, 30, 90)
+
+	var buf bytes.Buffer
+	if err := tree.Format(&buf, FormatOptions{ShowHeight: true}); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+	expected := "" +
+		"    .-- 90 h=1\n" +
+		".-- 80 h=2\n" +
+		"50 h=3\n" +
+		"|   .-- 30 h=1\n" +
+		"`-- 20 h=2\n" +
+		"    `-- 10 h=1\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	tree.Format(&buf, FormatOptions{MaxDepth: 1})
+	if s := buf.String(); s != "50 (+5 more)\n" {
+		t.Errorf("Expected depth-limited output \"50 (+5 more)\", got %q instead", s)
+	}
+}
+
+func TestTreeWriteDOT(t *testing.T) {
+	tree := &Tree[string, int]{}
+	tree.Insert(`b"`, 1)
+	tree.Insert("a", 2)
+
+	var buf bytes.Buffer
+	if err := tree.WriteDOT(&buf); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		"digraph bst {\n",
+		`n0 [label="b\"\nh=2 bf=1"];`,
+		`n1 [label="a\nh=1 bf=0"];`,
+		"n0 -> n1;",
+		"n2 [shape=point];",
+		"n0 -> n2;",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected DOT output to contain %q, got:\n%s", s, out)
+		}
+	}
+}
+
+func TestSyncTree(t *testing.T) {
+	s := &SyncTree[string, int]{}
+
+	s.Store("a", 1)
+	if v, loaded := s.LoadOrStore("a", 2); !loaded || v != 1 {
+		t.Errorf("Expected LoadOrStore(\"a\", 2) to load 1, got (%d, %t) instead", v, loaded)
+	}
+	if v, loaded := s.LoadOrStore("b", 2); loaded || v != 2 {
+		t.Errorf("Expected LoadOrStore(\"b\", 2) to store 2, got (%d, %t) instead", v, loaded)
+	}
+	if s.CompareAndSwap("a", 5, 10) {
+		t.Error("Expected CompareAndSwap(\"a\", 5, 10) to fail")
+	}
+	if !s.CompareAndSwap("a", 1, 10) {
+		t.Error("Expected CompareAndSwap(\"a\", 1, 10) to succeed")
+	}
+	if v, ok := s.Load("a"); !ok || v != 10 {
+		t.Errorf("Expected Load(\"a\") to be (10, true), got (%d, %t) instead", v, ok)
+	}
+	if s.CompareAndDelete("b", 3) || !s.CompareAndDelete("b", 2) {
+		t.Error("Expected only CompareAndDelete(\"b\", 2) to succeed")
+	}
+	if v, ok := s.LoadAndDelete("a"); !ok || v != 10 {
+		t.Errorf("Expected LoadAndDelete(\"a\") to be (10, true), got (%d, %t) instead", v, ok)
+	}
+	if n := s.Size(); n != 0 {
+		t.Errorf("Expected size to be 0, got %d instead", n)
+	}
+}
+
+func TestSyncTreeRange(t *testing.T) {
+	s := NewSync(newTestTree(1, 2, 3, 4, 5))
+
+	for k := range s.Range(2, 4) {
+		s.Delete(k)
+	}
+
+	if keys := s.Keys(); len(keys) != 2 || keys[0] != 1 || keys[1] != 5 {
+		t.Errorf("Expected keys [1 5], got %v instead", keys)
+	}
+}
+
+func TestSyncTreeConcurrent(t *testing.T) {
+	for _, s := range []*SyncTree[int, int]{NewSync(New[int, int]()), NewStripedSync(New[int, int](), 8)} {
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 500; i++ {
+					s.Update(i%50, func(old int, exists bool) (int, bool) {
+						return old + 1, true
+					})
+					s.Load(i % 50)
+				}
+			}()
+		}
+		wg.Wait()
+
+		total := 0
+		for _, v := range s.All() {
+			total += v
+		}
+		if total != 8*500 {
+			t.Errorf("Expected total to be %d, got %d instead", 8*500, total)
+		}
+	}
+}
+
+func TestTreeClone(t *testing.T) {
+	tree := newTestTree(50, 20, 80, 10, 30)
+	clone := tree.Clone()
+
+	if !tree.Equal(clone, func(a, b string) bool { return a == b }) {
+		t.Fatal("Expected clone to be equal to the original")
+	}
+	if clone.Height() != tree.Height() || clone.root.key != tree.root.key {
+		t.Error("Expected clone to have the same shape as the original")
+	}
+
+	clone.Insert(60, "60")
+	clone.Put(10, "x")
+
+	if tree.Size() != 5 || tree.Find(60) != nil || tree.Find(10).Data() != "10" {
+		t.Error("Expected modifications of the clone not to affect the original")
+	}
+	if tree.Equal(clone, func(a, b string) bool { return a == b }) {
+		t.Error("Expected modified clone not to be equal to the original")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := newTestTree(1, 2, 3, 5, 8)
+	b := newTestTree(2, 3, 4, 8, 9)
+	b.Put(3, "three")
+
+	var got []string
+	for c := range Diff(a, b, func(x, y string) bool { return x == y }) {
+		got = append(got, fmt.Sprintf("%s %d %q %q", c.Kind, c.Key, c.Old, c.New))
+	}
+
+	expected := []string{
+		`removed 1 "1" ""`,
+		`changed 3 "3" "three"`,
+		`added 4 "" "4"`,
+		`removed 5 "5" ""`,
+		`added 9 "" "9"`,
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d changes, got %v instead", len(expected), got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("Expected change %d to be %s, got %s instead", i, expected[i], got[i])
+		}
+	}
+
+	n := 0
+	for range Diff(nil, b, nil) {
+		n++
+	}
+	if n != b.Size() {
+		t.Errorf("Expected %d additions from an empty tree, got %d instead", b.Size(), n)
+	}
+}
+
+func TestTreePopMinMax(t *testing.T) {
+	tree := &Tree[int, string]{}
+	for i := 0; i < 50; i++ {
+		tree.Insert((i*13)%50, strconv.Itoa((i*13)%50))
+	}
+
+	for i := 0; i < 25; i++ {
+		k, v, ok := tree.PopMin()
+		if !ok || k != i || v != strconv.Itoa(i) {
+			t.Fatalf("Expected PopMin() to return (%d, %q, true), got (%d, %q, %t) instead", i, strconv.Itoa(i), k, v, ok)
+		}
+		k, _, ok = tree.PopMax()
+		if !ok || k != 49-i {
+			t.Fatalf("Expected PopMax() to return %d, got (%d, %t) instead", 49-i, k, ok)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, ok := tree.PopMin(); ok {
+		t.Error("Expected PopMin() on an empty tree to fail")
+	}
+	if _, _, ok := tree.PopMax(); ok {
+		t.Error("Expected PopMax() on an empty tree to fail")
+	}
+}
+
+func TestTopK(t *testing.T) {
+	top := NewTopK[int, string](3, RetainLargest)
+
+	for _, k := range []int{5, 1, 9, 7} {
+		top.Insert(k, strconv.Itoa(k))
+	}
+	if keys := top.Keys(); len(keys) != 3 || keys[0] != 5 || keys[2] != 9 {
+		t.Fatalf("Expected keys [5 7 9], got %v instead", keys)
+	}
+
+	if k, _, evicted := top.Insert(2, "2"); !evicted || k != 2 {
+		t.Errorf("Expected Insert(2) to evict 2, got (%d, %t) instead", k, evicted)
+	}
+	if k, _, evicted := top.Insert(8, "8"); !evicted || k != 5 {
+		t.Errorf("Expected Insert(8) to evict 5, got (%d, %t) instead", k, evicted)
+	}
+	if _, _, evicted := top.Insert(9, "nine"); evicted {
+		t.Error("Expected Insert(9) of an existing key not to evict")
+	}
+	if v := top.Find(9).Data(); v != "nine" {
+		t.Errorf("Expected value of 9 to be \"nine\", got %q instead", v)
+	}
+
+	bottom := NewTopK[int, string](2, RetainSmallest)
+	for _, k := range []int{5, 1, 9, 3} {
+		bottom.Insert(k, "")
+	}
+	if keys := bottom.Keys(); len(keys) != 2 || keys[0] != 1 || keys[1] != 3 {
+		t.Errorf("Expected keys [1 3], got %v instead", keys)
+	}
+	if n := bottom.Cap(); n != 2 {
+		t.Errorf("Expected Cap() to be 2, got %d instead", n)
+	}
+}
+
+func TestTopKZero(t *testing.T) {
+	var nilTop *TopK[int, string]
+	for range nilTop.All() {
+		t.Error("Expected All() on a nil TopK to yield nothing")
+	}
+	for range nilTop.Backward() {
+		t.Error("Expected Backward() on a nil TopK to yield nothing")
+	}
+
+	defer func() {
+		r := recover()
+		if msg, ok := r.(string); !ok || !strings.HasPrefix(msg, "bst: ") {
+			t.Fatalf("Expected a bst panic after Insert() on a zero TopK, got %v instead", r)
+		}
+	}()
+
+	var top TopK[int, string]
+	top.Insert(1, "1")
+}
+
+func TestTreeDeleteRange(t *testing.T) {
+	tests := []struct {
+		lo, hi, removed int
+	}{
+		{lo: 10, hi: 19, removed: 10},
+		{lo: -5, hi: 4, removed: 5},
+		{lo: 95, hi: 200, removed: 5},
+		{lo: 0, hi: 99, removed: 100},
+		{lo: 50, hi: 50, removed: 1},
+		{lo: 60, hi: 40, removed: 0},
+	}
+
+	for _, tt := range tests {
+		tree := &Tree[int, int]{}
+		for i := 0; i < 100; i++ {
+			tree.Insert(i, i)
+		}
+
+		if n := tree.DeleteRange(tt.lo, tt.hi); n != tt.removed {
+			t.Errorf("Expected DeleteRange(%d, %d) to remove %d keys, got %d instead", tt.lo, tt.hi, tt.removed, n)
+		}
+		if n := tree.Size(); n != 100-tt.removed {
+			t.Errorf("Expected size to be %d, got %d instead", 100-tt.removed, n)
+		}
+		if n := tree.CountRange(tt.lo, tt.hi); n != 0 {
+			t.Errorf("Expected no keys in [%d, %d], got %d instead", tt.lo, tt.hi, n)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTreeDeleteFunc(t *testing.T) {
+	tree := &Tree[int, int]{}
+	for i := 0; i < 1000; i++ {
+		tree.Insert(i, i*i)
+	}
+
+	n := tree.DeleteFunc(func(k, v int) bool {
+		return k%10 != 0 || v > 250000
+	})
+
+	if n != 949 {
+		t.Errorf("Expected DeleteFunc() to remove 949 keys, got %d instead", n)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if n := tree.Size(); n != 51 {
+		t.Errorf("Expected size to be 51, got %d instead", n)
+	}
+	for i, k := range tree.Keys() {
+		if k != i*10 {
+			t.Fatalf("Expected key at idx %d to be %d, got %d instead", i, i*10, k)
+		}
+	}
+}
+
+func TestRBTreeRandom(t *testing.T) {
+	tree := &RBTree[int, int]{}
+	ref := make(map[int]int)
+
+	for i := range 5000 {
+		k := rand.IntN(500)
+		if rand.IntN(2) == 0 {
+			tree.Insert(k, i)
+			if _, ok := ref[k]; !ok {
+				ref[k] = i
+			}
+		} else {
+			tree.Remove(k)
+			delete(ref, k)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("after %d operations: %v", i, err)
+		}
+	}
+
+	if tree.Size() != len(ref) {
+		t.Errorf("Expected size to be %d, got %d instead", len(ref), tree.Size())
+	}
+	for k, v := range ref {
+		if node := tree.Find(k); node == nil || node.Data() != v {
+			t.Fatalf("Expected to find %d with %d, got %v instead", k, v, node)
+		}
+	}
+	keys := tree.Keys()
+	if !slices.IsSorted(keys) {
+		t.Errorf("Expected keys in ascending order")
+	}
+
+	var backward []int
+	for k := range tree.Backward() {
+		backward = append(backward, k)
+	}
+	slices.Reverse(backward)
+	if !slices.Equal(backward, keys) {
+		t.Errorf("Expected Backward() to yield the keys in descending order")
+	}
+
+	var inRange []int
+	for k, v := range tree.Range(100, 300) {
+		if v != ref[k] {
+			t.Errorf("Expected Range() to yield %d for %d, got %d instead", ref[k], k, v)
+		}
+		inRange = append(inRange, k)
+	}
+	lo, _ := slices.BinarySearch(keys, 100)
+	hi, _ := slices.BinarySearch(keys, 301)
+	if !slices.Equal(inRange, keys[lo:hi]) {
+		t.Errorf("Expected Range(100, 300) to yield %v, got %v instead", keys[lo:hi], inRange)
+	}
+}
+
+func TestRBTreeHeight(t *testing.T) {
+	tree := NewRB[int, struct{}]()
+	for i := range 1 << 12 {
+		tree.Insert(i, struct{}{})
+	}
+	// A red-black tree with n nodes is at most 2*log2(n+1) high.
+	if h := tree.Height(); h > 24 {
+		t.Errorf("Expected height of at most 24, got %d instead", h)
+	}
+	if min, max := tree.Min(), tree.Max(); min.Key() != 0 || max.Key() != 1<<12-1 {
+		t.Errorf("Expected min 0 and max %d, got %v and %v instead", 1<<12-1, min, max)
+	}
+	for i := range 1 << 12 {
+		tree.Remove(i)
+	}
+	if tree.Size() != 0 || tree.Height() != 0 {
+		t.Errorf("Expected an empty tree, got size %d and height %d instead", tree.Size(), tree.Height())
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	maps := map[string]OrderedMap[string, int]{
+		"avl":       New[string, int](),
+		"func":      NewFunc[string, int](strings.Compare),
+		"rb":        NewRB[string, int](),
+		"augmented": NewAugmented(func(a, b int) int { return a + b }, func(k string, v int) int { return v }),
+	}
+
+	for name, m := range maps {
+		for i, k := range []string{"d", "b", "f", "a", "c", "e", "g"} {
+			m.Insert(k, i)
+		}
+		m.Remove("d")
+		m.Remove("z")
+
+		if got := strings.Join(m.Keys(), ""); got != "abcefg" {
+			t.Errorf("%s: Expected keys abcefg, got %s instead", name, got)
+		}
+		if v, ok := m.Get("f"); !ok || v != 2 {
+			t.Errorf("%s: Expected f to be 2, got (%d, %t) instead", name, v, ok)
+		}
+		if _, ok := m.Get("d"); ok {
+			t.Errorf("%s: Expected d to be missing", name)
+		}
+
+		var got []string
+		for k := range m.Range("b", "e") {
+			got = append(got, k)
+		}
+		for k := range m.All() {
+			got = append(got, k)
+			break
+		}
+		if want := []string{"b", "c", "e", "a"}; !slices.Equal(got, want) {
+			t.Errorf("%s: Expected %v, got %v instead", name, want, got)
+		}
+		if m.Size() != 6 {
+			t.Errorf("%s: Expected size to be 6, got %d instead", name, m.Size())
+		}
+	}
+}
+
+type recordingObserver struct {
+	rotations []string
+}
+
+func (o *recordingObserver) Rotated(dir Direction, double bool) {
+	o.rotations = append(o.rotations, fmt.Sprintf("%v/%v", dir, double))
+}
+
+func TestTreeStats(t *testing.T) {
+	tree := &Tree[int, string]{}
+	if s := tree.Stats(); s != (Stats{}) {
+		t.Errorf("Expected zero stats before EnableStats(), got %+v instead", s)
+	}
+
+	obs := &recordingObserver{}
+	tree.EnableStats()
+	tree.SetObserver(obs)
+
+	// 1, 2, 3 needs a single left rotation, 3 then 5, 4 a double one, and
+	// removing 1 leaves 2 right-heavy, which takes another single rotation.
+	for _, k := range []int{1, 2, 3, 5, 4} {
+		tree.Insert(k, strconv.Itoa(k))
+	}
+	tree.Insert(4, "dup")
+	tree.Find(2)
+	tree.Find(5)
+	tree.Find(6)
+	tree.Remove(1)
+	tree.Remove(7)
+
+	want := Stats{
+		Inserts:         5,
+		Removes:         1,
+		SingleRotations: 2,
+		DoubleRotations: 1,
+		Searches:        3,
+		AvgSearchDepth:  7.0 / 3,
+		MaxSearchDepth:  3,
+	}
+	if s := tree.Stats(); s != want {
+		t.Errorf("Expected %+v, got %+v instead", want, s)
+	}
+
+	wantRotations := []string{"left/false", "right/true", "left/true", "left/false"}
+	if !slices.Equal(obs.rotations, wantRotations) {
+		t.Errorf("Expected rotations %v, got %v instead", wantRotations, obs.rotations)
+	}
+}
+
+func TestTreeStatsBulk(t *testing.T) {
+	tree := newTestTree(1, 2, 3)
+	obs := &recordingObserver{}
+	tree.EnableStats()
+	tree.SetObserver(obs)
+
+	tree.Put(4, "4")
+	tree.GetOrInsert(1, "x")
+	tree.Update(2, func(old string, _ bool) (string, bool) { return old, true })
+	tree.Delete(3)
+	if s := tree.Stats(); s.Searches != 4 || s.Inserts != 1 || s.Removes != 1 {
+		t.Errorf("Expected 4 searches, 1 insert and 1 remove, got %+v instead", s)
+	}
+
+	before := tree.Stats()
+	tree.UnmarshalJSON([]byte(`[{"key":2,"value":"b"},{"key":1,"value":"a"}]`))
+	tree.Union(newTestTree(7, 8))
+	tree.Difference(tree)
+	if s := tree.Stats(); s != before {
+		t.Errorf("Expected bulk operations not to be counted, got %+v instead of %+v", s, before)
+	}
+
+	tree.Insert(5, "5")
+	left, right := tree.Split(5)
+	for _, derived := range []*Tree[int, string]{left, right, right.Clone(), Join(left, right)} {
+		if derived.observer != obs || derived.stats == nil || derived.stats == tree.stats {
+			t.Errorf("Expected a derived tree to keep the observer and have stats of its own")
+		}
+	}
+}
+
+func TestTreeStatsConcurrentFind(t *testing.T) {
+	tree := newTestTree(1, 2, 3, 4, 5, 6, 7)
+	tree.EnableStats()
+	s := NewSync(tree)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range 100 {
+				s.Load(k % 8)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if st := s.tree.Stats(); st.Searches != 800 || st.MaxSearchDepth != 3 {
+		t.Errorf("Expected 800 searches with a max depth of 3, got %+v instead", st)
+	}
+}
+
+const benchSize = 1 << 16
+
+func BenchmarkTreeInsert(b *testing.B) {
+	keys := rand.Perm(benchSize)
+	for range b.N {
+		tree := &Tree[int, int]{}
+		for _, k := range keys {
+			tree.Insert(k, k)
+		}
+	}
+}
+
+func BenchmarkTreeFind(b *testing.B) {
+	keys := rand.Perm(benchSize)
+	tree := &Tree[int, int]{}
+	for _, k := range keys {
+		tree.Insert(k, k)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		tree.Find(keys[i%benchSize])
+	}
+}
+
+func BenchmarkAugmentedPut(b *testing.B) {
+	keys := rand.Perm(benchSize)
+	tree := NewAugmented(func(a, b int) int { return a + b }, func(k, v int) int { return v })
+	for _, k := range keys {
+		tree.Insert(k, k)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		tree.Put(keys[i%benchSize], i)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"iter"
+)
+
+// FromSorted builds a balanced tree from keys in strictly ascending order and
+// their values in O(n) time.
+func FromSorted[K cmp.Ordered, V any](keys []K, vals []V) *Tree[K, V] {
+	if len(keys) != len(vals) {
+		panic("bst: called FromSorted() with keys and values of different length")
+	}
+	if !isSorted(keys) {
+		panic("bst: called FromSorted() with keys that are not in strictly ascending order")
+	}
+	tree := &Tree[K, V]{avl: avl[K, V]{size: len(keys)}}
+	tree.root = tree.build(keys, vals)
+	return tree
+}
+
+// FromSeq builds a balanced tree from a sequence of key-value pairs in strictly
+// ascending key order in O(n) time.
+func FromSeq[K cmp.Ordered, V any](seq iter.Seq2[K, V]) *Tree[K, V] {
+	var keys []K
+	var vals []V
+	for k, v := range seq {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	if !isSorted(keys) {
+		panic("bst: called FromSeq() with keys that are not in strictly ascending order")
+	}
+	tree := &Tree[K, V]{avl: avl[K, V]{size: len(keys)}}
+	tree.root = tree.build(keys, vals)
+	return tree
+}
+
+// build links the sorted entries into a perfectly balanced subtree rooted at
+// the middle entry.
+func (tree *avl[K, V]) build(keys []K, vals []V) *Node[K, V] {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	mid := len(keys) / 2
+	node := &Node[K, V]{
+		key:   keys[mid],
+		left:  tree.build(keys[:mid], vals[:mid]),
+		right: tree.build(keys[mid+1:], vals[mid+1:]),
+		data:  vals[mid],
+	}
+	tree.update(node)
+	return node
+}
+
+func isSorted[K cmp.Ordered](keys []K) bool {
+	for i := 1; i < len(keys); i++ {
+		if cmp.Compare(keys[i-1], keys[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
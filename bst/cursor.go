@@ -0,0 +1,189 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import "cmp"
+
+// Cursor is a position in a tree that can move in both directions and delete
+// the entry it points to. Any structural modification of the tree that is not
+// made through the cursor invalidates it: moving, reading or deleting through
+// an invalidated cursor panics until it is repositioned with First, Last or Seek.
+type Cursor[K cmp.Ordered, V any] struct {
+	tree *Tree[K, V]
+	// path holds the nodes from the root to the current one, so that Next and
+	// Prev take amortized O(1) time instead of searching from the root.
+	path []*Node[K, V]
+	mods int
+}
+
+// Cursor returns a cursor over the tree that is not positioned at any entry.
+func (tree *Tree[K, V]) Cursor() *Cursor[K, V] {
+	if tree == nil {
+		panic("bst: called Cursor() on a nil tree")
+	}
+	return &Cursor[K, V]{tree: tree, mods: tree.mods}
+}
+
+// First moves the cursor to the smallest key and reports whether it exists.
+func (c *Cursor[K, V]) First() bool {
+	c.reset()
+	c.pushEdge(c.tree.root, false)
+	return len(c.path) > 0
+}
+
+// Last moves the cursor to the greatest key and reports whether it exists.
+func (c *Cursor[K, V]) Last() bool {
+	c.reset()
+	c.pushEdge(c.tree.root, true)
+	return len(c.path) > 0
+}
+
+// Seek moves the cursor to the smallest key greater than or equal to key and
+// reports whether it exists.
+func (c *Cursor[K, V]) Seek(key K) bool {
+	c.seek(key, true)
+	return len(c.path) > 0
+}
+
+// Next moves the cursor to the next key and reports whether it exists.
+func (c *Cursor[K, V]) Next() bool {
+	c.checkValid("Next")
+	c.step(false)
+	return len(c.path) > 0
+}
+
+// Prev moves the cursor to the previous key and reports whether it exists.
+func (c *Cursor[K, V]) Prev() bool {
+	c.checkValid("Prev")
+	c.step(true)
+	return len(c.path) > 0
+}
+
+// Valid reports whether the cursor points to an entry.
+func (c *Cursor[K, V]) Valid() bool {
+	return len(c.path) > 0 && c.mods == c.tree.mods
+}
+
+func (c *Cursor[K, V]) Key() K {
+	c.checkValid("Key")
+	return c.node().key
+}
+
+func (c *Cursor[K, V]) Value() V {
+	c.checkValid("Value")
+	return c.node().data
+}
+
+// Delete removes the current entry and moves the cursor to the next key. It
+// reports whether the cursor points to an entry afterwards.
+func (c *Cursor[K, V]) Delete() bool {
+	c.checkValid("Delete")
+
+	key := c.node().key
+	c.tree.root = c.tree.remove(c.tree.root, key)
+	c.tree.check()
+
+	// Removing rebalances the tree, so the path is rebuilt from the root.
+	c.seek(key, false)
+	return len(c.path) > 0
+}
+
+func (c *Cursor[K, V]) node() *Node[K, V] {
+	return c.path[len(c.path)-1]
+}
+
+func (c *Cursor[K, V]) reset() {
+	c.mods = c.tree.mods
+	if c.path == nil {
+		c.path = make([]*Node[K, V], 0, height(c.tree.root))
+	}
+	c.path = c.path[:0]
+}
+
+// pushEdge pushes node and its leftmost (rightmost when reverse is set) descendants.
+func (c *Cursor[K, V]) pushEdge(node *Node[K, V], reverse bool) {
+	for node != nil {
+		c.path = append(c.path, node)
+		if reverse {
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+}
+
+// seek positions the cursor at the smallest key greater than key, or equal to
+// it when inclusive is set. That node is on the search path for key, so the
+// path is cut back to it once the search ends.
+func (c *Cursor[K, V]) seek(key K, inclusive bool) {
+	c.reset()
+	found := 0
+	for node := c.tree.root; node != nil; {
+		c.path = append(c.path, node)
+		r := cmp.Compare(key, node.key)
+		if r == 0 && inclusive {
+			return
+		}
+		if r < 0 {
+			found = len(c.path)
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	c.path = c.path[:found]
+}
+
+// step moves to the in-order successor of the current node, or to its
+// predecessor when reverse is set.
+func (c *Cursor[K, V]) step(reverse bool) {
+	node := c.node()
+	child := node.right
+	if reverse {
+		child = node.left
+	}
+	if child != nil {
+		c.pushEdge(child, reverse)
+		return
+	}
+
+	// Otherwise it is the nearest ancestor that has node in its left subtree
+	// (right subtree when reverse is set).
+	for len(c.path) > 1 {
+		c.path = c.path[:len(c.path)-1]
+		parent := c.node()
+		if (!reverse && parent.left == node) || (reverse && parent.right == node) {
+			return
+		}
+		node = parent
+	}
+	c.path = c.path[:0]
+}
+
+func (c *Cursor[K, V]) checkValid(method string) {
+	if c.mods != c.tree.mods {
+		panic("bst: called " + method + "() on a cursor after the tree was modified")
+	}
+	if len(c.path) == 0 {
+		panic("bst: called " + method + "() on a cursor that is not positioned")
+	}
+}
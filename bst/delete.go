@@ -0,0 +1,91 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import "cmp"
+
+// DeleteRange removes every key with lo <= key <= hi and returns how many keys
+// were removed. The tree is split around the range and the remaining parts
+// are joined, so it runs in O(log n) time plus the time to drop the range.
+func (tree *Tree[K, V]) DeleteRange(lo, hi K) int {
+	if tree == nil {
+		panic("bst: called DeleteRange() on a nil tree")
+	}
+	if tree.root == nil || cmp.Compare(lo, hi) > 0 {
+		return 0
+	}
+
+	left, _, rest := tree.split(tree.root, lo)
+	_, _, right := tree.split(rest, hi)
+	tree.root = tree.join2(left, right)
+
+	n := tree.size - getSize(tree.root)
+	tree.size -= n
+	tree.mods++
+	tree.stats.removed(n)
+	tree.check()
+	return n
+}
+
+// DeleteFunc removes every entry for which pred returns true and returns how
+// many entries were removed. The remaining nodes are relinked into a balanced
+// tree in a single O(n) pass instead of being removed one by one.
+func (tree *Tree[K, V]) DeleteFunc(pred func(key K, val V) bool) int {
+	if tree == nil {
+		panic("bst: called DeleteFunc() on a nil tree")
+	}
+
+	keep := make([]*Node[K, V], 0, tree.size)
+	it := newIterator(tree.root, false)
+	for node := it.next(); node != nil; node = it.next() {
+		if !pred(node.key, node.data) {
+			keep = append(keep, node)
+		}
+	}
+
+	n := tree.size - len(keep)
+	if n == 0 {
+		return 0
+	}
+
+	tree.root = tree.relink(keep)
+	tree.size = len(keep)
+	tree.mods++
+	tree.stats.removed(n)
+	tree.check()
+	return n
+}
+
+// relink arranges the sorted nodes into a perfectly balanced subtree, reusing
+// the nodes themselves.
+func (tree *avl[K, V]) relink(nodes []*Node[K, V]) *Node[K, V] {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	mid := len(nodes) / 2
+	node := nodes[mid]
+	node.left = tree.relink(nodes[:mid])
+	node.right = tree.relink(nodes[mid+1:])
+	tree.update(node)
+	return node
+}
@@ -0,0 +1,155 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"iter"
+)
+
+// ChangeKind describes how an entry differs between two trees.
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota + 1
+	Removed
+	Changed
+)
+
+func (kind ChangeKind) String() string {
+	switch kind {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// Change is a single difference reported by Diff. Old is set for removed and
+// changed entries, New for added and changed entries.
+type Change[K any, V any] struct {
+	Kind ChangeKind
+	Key  K
+	Old  V
+	New  V
+}
+
+// Clone returns a copy of the tree with the same shape, augmentation and
+// observer, which collects stats of its own if the tree does. It runs in O(n)
+// time and does not rebalance.
+func (tree *Tree[K, V]) Clone() *Tree[K, V] {
+	if tree == nil {
+		return nil
+	}
+	c := &Tree[K, V]{avl: avl[K, V]{
+		root: cloneTree(tree.root),
+		size: tree.size,
+		aug:  tree.aug,
+	}}
+	c.instrument(&tree.avl)
+	return c
+}
+
+// Equal reports whether both trees hold the same keys and eqV reports their
+// values as equal.
+func (tree *Tree[K, V]) Equal(other *Tree[K, V], eqV func(a, b V) bool) bool {
+	if tree.Size() != other.Size() {
+		return false
+	}
+	if tree.Size() == 0 {
+		return true
+	}
+
+	a, b := newIterator(tree.root, false), newIterator(other.root, false)
+	for x, y := a.next(), b.next(); x != nil; x, y = a.next(), b.next() {
+		if cmp.Compare(x.key, y.key) != 0 || !eqV(x.data, y.data) {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff returns an iterator over the changes that turn a into b, in ascending
+// key order. If eqV is nil, values are not compared and no Changed entries
+// are reported.
+func Diff[K cmp.Ordered, V any](a, b *Tree[K, V], eqV func(x, y V) bool) iter.Seq[Change[K, V]] {
+	return func(yield func(Change[K, V]) bool) {
+		var rootA, rootB *Node[K, V]
+		if a != nil {
+			rootA = a.root
+		}
+		if b != nil {
+			rootB = b.root
+		}
+		if rootA == nil && rootB == nil {
+			return
+		}
+
+		ia, ib := newIterator(rootA, false), newIterator(rootB, false)
+		x, y := ia.next(), ib.next()
+		for x != nil || y != nil {
+			var change Change[K, V]
+
+			c := 0
+			if x == nil {
+				c = 1
+			} else if y == nil {
+				c = -1
+			} else {
+				c = cmp.Compare(x.key, y.key)
+			}
+
+			switch {
+			case c < 0:
+				change = Change[K, V]{Kind: Removed, Key: x.key, Old: x.data}
+				x = ia.next()
+			case c > 0:
+				change = Change[K, V]{Kind: Added, Key: y.key, New: y.data}
+				y = ib.next()
+			default:
+				changed := eqV != nil && !eqV(x.data, y.data)
+				change = Change[K, V]{Kind: Changed, Key: x.key, Old: x.data, New: y.data}
+				x, y = ia.next(), ib.next()
+				if !changed {
+					continue
+				}
+			}
+
+			if !yield(change) {
+				return
+			}
+		}
+	}
+}
+
+func cloneTree[K any, V any](node *Node[K, V]) *Node[K, V] {
+	if node == nil {
+		return nil
+	}
+	n := cloneNode(node)
+	n.left = cloneTree(node.left)
+	n.right = cloneTree(node.right)
+	return n
+}
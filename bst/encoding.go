@@ -0,0 +1,147 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// binaryVersion is the version of the format written by MarshalBinary. It is
+// stored in the first byte of the encoding.
+const binaryVersion = 1
+
+// entry is the JSON representation of a key-value pair.
+type entry[K any, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// entries is the gob payload of the binary encoding.
+type entries[K any, V any] struct {
+	Keys []K
+	Vals []V
+}
+
+// MarshalJSON encodes the tree as an array of {"key": ..., "value": ...}
+// objects in ascending key order.
+func (tree *Tree[K, V]) MarshalJSON() ([]byte, error) {
+	out := make([]entry[K, V], 0, tree.Size())
+	if tree != nil {
+		for k, v := range tree.All() {
+			out = append(out, entry[K, V]{Key: k, Value: v})
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON replaces the contents of the tree with the decoded pairs. Pairs
+// in ascending key order are loaded in linear time; otherwise they are
+// inserted one by one and later duplicates replace earlier ones.
+func (tree *Tree[K, V]) UnmarshalJSON(data []byte) error {
+	if tree == nil {
+		panic("bst: called UnmarshalJSON() on a nil tree")
+	}
+
+	var in []entry[K, V]
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	keys := make([]K, len(in))
+	vals := make([]V, len(in))
+	for i, e := range in {
+		keys[i], vals[i] = e.Key, e.Value
+	}
+
+	if isSorted(keys) {
+		tree.root, tree.size = tree.build(keys, vals), len(keys)
+		tree.mods++
+		tree.check()
+		return nil
+	}
+
+	// Decoding replaces the whole tree, so the inserts are not counted.
+	s := tree.stats
+	tree.stats = nil
+	tree.root, tree.size = nil, 0
+	tree.mods++
+	for i := range keys {
+		tree.Put(keys[i], vals[i])
+	}
+	tree.stats = s
+	return nil
+}
+
+// MarshalBinary encodes the tree as a version byte followed by the gob
+// encoding of its keys and values in ascending key order.
+func (tree *Tree[K, V]) MarshalBinary() ([]byte, error) {
+	payload := entries[K, V]{
+		Keys: make([]K, 0, tree.Size()),
+		Vals: make([]V, 0, tree.Size()),
+	}
+	if tree != nil {
+		for k, v := range tree.All() {
+			payload.Keys = append(payload.Keys, k)
+			payload.Vals = append(payload.Vals, v)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(binaryVersion)
+	if err := gob.NewEncoder(&buf).Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the contents of the tree with data produced by MarshalBinary.
+func (tree *Tree[K, V]) UnmarshalBinary(data []byte) error {
+	if tree == nil {
+		panic("bst: called UnmarshalBinary() on a nil tree")
+	}
+	if len(data) == 0 {
+		return errors.New("bst: empty binary encoding")
+	}
+	if data[0] != binaryVersion {
+		return fmt.Errorf("bst: unsupported binary encoding version %d", data[0])
+	}
+
+	var payload entries[K, V]
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&payload); err != nil {
+		return err
+	}
+	if len(payload.Keys) != len(payload.Vals) {
+		return errors.New("bst: corrupted binary encoding: key and value counts differ")
+	}
+
+	if !isSorted(payload.Keys) {
+		return errors.New("bst: corrupted binary encoding: keys are not in ascending order")
+	}
+	tree.root, tree.size = tree.build(payload.Keys, payload.Vals), len(payload.Keys)
+	tree.mods++
+	tree.check()
+	return nil
+}
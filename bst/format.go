@@ -0,0 +1,116 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FormatOptions controls the output of Tree.Format.
+type FormatOptions struct {
+	// MaxDepth limits the number of levels drawn. Subtrees below the limit
+	// are replaced by a count of their nodes. Zero means no limit.
+	MaxDepth int
+	// ShowHeight appends the height of every node to its key.
+	ShowHeight bool
+	// ShowBalance appends the balance factor of every node to its key.
+	ShowBalance bool
+}
+
+// WriteDOT writes the structure of the tree in the Graphviz DOT language.
+// Every node is labeled with its key, height and balance factor. Missing
+// children of inner nodes are drawn as points to keep left and right apart.
+func (tree *Tree[K, V]) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph bst {")
+	fmt.Fprintln(bw, "\tnode [shape=box, fontname=\"monospace\"];")
+
+	if tree != nil {
+		id := 0
+		var walk func(node *Node[K, V]) int
+		walk = func(node *Node[K, V]) int {
+			self := id
+			id++
+			if node == nil {
+				fmt.Fprintf(bw, "\tn%d [shape=point];\n", self)
+				return self
+			}
+
+			key := escapeDOT(fmt.Sprintf("%v", node.key))
+			fmt.Fprintf(bw, "\tn%d [label=\"%s\\nh=%d bf=%d\"];\n", self, key, node.height, getBalance(node))
+			if node.left != nil || node.right != nil {
+				fmt.Fprintf(bw, "\tn%d -> n%d;\n", self, walk(node.left))
+				fmt.Fprintf(bw, "\tn%d -> n%d;\n", self, walk(node.right))
+			}
+			return self
+		}
+		if tree.root != nil {
+			walk(tree.root)
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// Format draws the tree sideways with the root on the left and greater keys
+// above smaller ones.
+func (tree *Tree[K, V]) Format(w io.Writer, opts FormatOptions) error {
+	bw := bufio.NewWriter(w)
+	if tree != nil && tree.root != nil {
+		formatNode(bw, tree.root, "", "", "", 1, opts)
+	}
+	return bw.Flush()
+}
+
+// formatNode writes the subtree with the given prefixes: above is used for the
+// lines of the right subtree, line for the node itself and below for the lines
+// of the left subtree.
+func formatNode[K any, V any](w *bufio.Writer, node *Node[K, V], above, line, below string, depth int, opts FormatOptions) {
+	label := fmt.Sprintf("%v", node.key)
+	if opts.ShowHeight {
+		label += fmt.Sprintf(" h=%d", node.height)
+	}
+	if opts.ShowBalance {
+		label += fmt.Sprintf(" bf=%d", getBalance(node))
+	}
+
+	if opts.MaxDepth > 0 && depth >= opts.MaxDepth && node.size > 1 {
+		fmt.Fprintf(w, "%s%s (+%d more)\n", line, label, node.size-1)
+		return
+	}
+
+	if node.right != nil {
+		formatNode(w, node.right, above+"    ", above+".-- ", above+"|   ", depth+1, opts)
+	}
+	fmt.Fprintf(w, "%s%s\n", line, label)
+	if node.left != nil {
+		formatNode(w, node.left, below+"|   ", below+"`-- ", below+"    ", depth+1, opts)
+	}
+}
+
+func escapeDOT(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
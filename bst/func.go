@@ -0,0 +1,318 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"fmt"
+	"iter"
+)
+
+// FuncTree is an AVL tree ordered by a comparison function, for keys that are
+// not cmp.Ordered such as structs, byte slices or time.Time. It shares the
+// balancing code of Tree, but every comparison is an indirect call, so keys
+// that are cmp.Ordered should use Tree. The zero FuncTree has no ordering;
+// use NewFunc.
+type FuncTree[K any, V any] struct {
+	avl[K, V]
+	cmp func(a, b K) int
+}
+
+// NewFunc returns an empty tree ordered by cmp, which must return a negative
+// number when a < b, a positive number when a > b and zero when a == b.
+func NewFunc[K any, V any](cmp func(a, b K) int) *FuncTree[K, V] {
+	if cmp == nil {
+		panic("bst: called NewFunc() with a nil comparison function")
+	}
+	return &FuncTree[K, V]{cmp: cmp}
+}
+
+// NewFuncAugmented returns an empty tree ordered by cmp whose values carry
+// state derived from their subtrees, such as the greatest endpoint in an
+// interval tree. fix is called bottom-up whenever the children of a node may
+// have changed, with the key and value of the node and the values of its
+// children, which are nil for missing children. It must only modify *data.
+func NewFuncAugmented[K any, V any](cmp func(a, b K) int, fix func(key K, data *V, left, right *V)) *FuncTree[K, V] {
+	if fix == nil {
+		panic("bst: called NewFuncAugmented() with a nil fix function")
+	}
+	tree := NewFunc[K, V](cmp)
+	tree.aug = fixer[K, V](fix)
+	return tree
+}
+
+// fixer adapts the fix function of NewFuncAugmented to an augmenter.
+type fixer[K any, V any] func(key K, data *V, left, right *V)
+
+func (fix fixer[K, V]) update(node *Node[K, V]) {
+	var left, right *V
+	if node.left != nil {
+		left = &node.left.data
+	}
+	if node.right != nil {
+		right = &node.right.data
+	}
+	fix(node.key, &node.data, left, right)
+}
+
+func (tree *FuncTree[K, V]) Insert(key K, data V) {
+	tree.checkInit("Insert")
+	tree.root = tree.insert(tree.root, key, data)
+	tree.check()
+}
+
+func (tree *FuncTree[K, V]) Find(key K) *Node[K, V] {
+	tree.checkInit("Find")
+	node := tree.root
+	for node != nil {
+		c := tree.cmp(key, node.key)
+		if c == 0 {
+			return node
+		}
+		if c < 0 {
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	return nil
+}
+
+// Get returns the value of key and whether it is present.
+func (tree *FuncTree[K, V]) Get(key K) (val V, ok bool) {
+	if node := tree.Find(key); node != nil {
+		return node.data, true
+	}
+	return val, false
+}
+
+// Put sets the value for key, as Tree.Put does.
+func (tree *FuncTree[K, V]) Put(key K, val V) (old V, replaced bool) {
+	tree.checkInit("Put")
+	if node := tree.Find(key); node != nil {
+		old, node.data = node.data, val
+		tree.refresh(tree.root, key)
+		return old, true
+	}
+	tree.root = tree.insert(tree.root, key, val)
+	tree.check()
+	return old, false
+}
+
+func (tree *FuncTree[K, V]) Remove(key K) {
+	tree.checkInit("Remove")
+	tree.root = tree.remove(tree.root, key)
+	tree.check()
+}
+
+func (tree *FuncTree[K, V]) Height() int {
+	if tree == nil {
+		return 0
+	}
+	return height(tree.root)
+}
+
+func (tree *FuncTree[K, V]) Size() int {
+	if tree == nil {
+		return 0
+	}
+	return tree.size
+}
+
+func (tree *FuncTree[K, V]) Keys() []K {
+	keys := make([]K, 0, tree.Size())
+	for key := range tree.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// All returns an iterator over the key-value pairs of the tree in ascending key order.
+func (tree *FuncTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil {
+			return
+		}
+		walk(tree.root, false, yield)
+	}
+}
+
+// Backward returns an iterator over the key-value pairs of the tree in descending key order.
+func (tree *FuncTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil {
+			return
+		}
+		walk(tree.root, true, yield)
+	}
+}
+
+// Range returns an iterator over the key-value pairs with lo <= key <= hi in ascending key order.
+func (tree *FuncTree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil || tree.root == nil || tree.cmp(lo, hi) > 0 {
+			return
+		}
+		it := &iterator[K, V]{}
+		for node := tree.root; node != nil; {
+			if tree.cmp(node.key, lo) >= 0 {
+				it.stack = append(it.stack, node)
+				node = node.left
+			} else {
+				node = node.right
+			}
+		}
+		for node := it.next(); node != nil && tree.cmp(node.key, hi) <= 0; node = it.next() {
+			if !yield(node.key, node.data) {
+				return
+			}
+		}
+	}
+}
+
+// Root returns the root node of the tree, or nil if it is empty. Together with
+// Node.Left and Node.Right it lets augmented trees prune their own searches.
+func (tree *FuncTree[K, V]) Root() *Node[K, V] {
+	if tree == nil {
+		return nil
+	}
+	return tree.root
+}
+
+func (tree *FuncTree[K, V]) Min() *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Min() on a nil tree")
+	}
+	return getMinNode(tree.root)
+}
+
+func (tree *FuncTree[K, V]) Max() *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Max() on a nil tree")
+	}
+	return getMaxNode(tree.root)
+}
+
+// Validate checks the structural invariants of the tree, as Tree.Validate does.
+func (tree *FuncTree[K, V]) Validate() error {
+	if tree == nil || tree.root == nil {
+		if tree != nil && tree.size != 0 {
+			return fmt.Errorf("bst: empty tree has size %d", tree.size)
+		}
+		return nil
+	}
+	if err := validate(tree.root, tree.cmp); err != nil {
+		return err
+	}
+	if tree.size != tree.root.size {
+		return fmt.Errorf("bst: tree size is %d, expected %d", tree.size, tree.root.size)
+	}
+	return nil
+}
+
+func (tree *FuncTree[K, V]) checkInit(method string) {
+	if tree == nil {
+		panic("bst: called " + method + "() on a nil tree")
+	}
+	if tree.cmp == nil {
+		panic("bst: called " + method + "() on a FuncTree not created by NewFunc()")
+	}
+}
+
+// check validates the tree after a modification when built with the bstdebug tag.
+func (tree *FuncTree[K, V]) check() {
+	if debug {
+		if err := tree.Validate(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// insert and remove are the counterparts of the Tree methods that compare keys
+// with tree.cmp.
+
+func (tree *FuncTree[K, V]) insert(node *Node[K, V], key K, data V) *Node[K, V] {
+	if node == nil {
+		tree.size++
+		tree.mods++
+		node = &Node[K, V]{key: key, height: 1, size: 1, data: data}
+		tree.update(node)
+		return node
+	}
+
+	if c := tree.cmp(key, node.key); c < 0 {
+		node.left = tree.insert(node.left, key, data)
+	} else if c > 0 {
+		node.right = tree.insert(node.right, key, data)
+	} else {
+		return node
+	}
+
+	return tree.rebalance(node)
+}
+
+func (tree *FuncTree[K, V]) remove(node *Node[K, V], key K) *Node[K, V] {
+	if node == nil {
+		return nil
+	}
+
+	if c := tree.cmp(key, node.key); c < 0 {
+		node.left = tree.remove(node.left, key)
+	} else if c > 0 {
+		node.right = tree.remove(node.right, key)
+	} else {
+		if node.left == nil || node.right == nil {
+			if node.left != nil {
+				node = node.left
+			} else {
+				node = node.right
+			}
+
+			tree.size--
+			tree.mods++
+		} else {
+			temp := getMinNode(node.right)
+			node.key = temp.key
+			node.data = temp.data
+			node.right = tree.remove(node.right, temp.key)
+		}
+	}
+
+	if node == nil {
+		return nil
+	}
+
+	return tree.rebalance(node)
+}
+
+// refresh recomputes the augmented values on the path to key after its value
+// was changed in place.
+func (tree *FuncTree[K, V]) refresh(node *Node[K, V], key K) {
+	if tree.aug == nil || node == nil {
+		return
+	}
+	if c := tree.cmp(key, node.key); c < 0 {
+		tree.refresh(node.left, key)
+	} else if c > 0 {
+		tree.refresh(node.right, key)
+	}
+	tree.aug.update(node)
+}
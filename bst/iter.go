@@ -0,0 +1,128 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"iter"
+)
+
+// All returns an iterator over the key-value pairs of the tree in ascending key order.
+func (tree *Tree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil {
+			return
+		}
+		walk(tree.root, false, yield)
+	}
+}
+
+// Backward returns an iterator over the key-value pairs of the tree in descending key order.
+func (tree *Tree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil {
+			return
+		}
+		walk(tree.root, true, yield)
+	}
+}
+
+// Range returns an iterator over the key-value pairs with lo <= key <= hi in ascending key order.
+func (tree *Tree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil || tree.root == nil {
+			return
+		}
+		walkRange(tree.root, lo, hi, yield)
+	}
+}
+
+func walk[K any, V any](root *Node[K, V], reverse bool, yield func(K, V) bool) {
+	it := newIterator(root, reverse)
+	for node := it.next(); node != nil; node = it.next() {
+		if !yield(node.key, node.data) {
+			return
+		}
+	}
+}
+
+func walkRange[K cmp.Ordered, V any](root *Node[K, V], lo, hi K, yield func(K, V) bool) {
+	if cmp.Compare(lo, hi) > 0 {
+		return
+	}
+	it := &iterator[K, V]{}
+	for node := root; node != nil; {
+		if cmp.Compare(node.key, lo) >= 0 {
+			it.stack = append(it.stack, node)
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	for node := it.next(); node != nil && cmp.Compare(node.key, hi) <= 0; node = it.next() {
+		if !yield(node.key, node.data) {
+			return
+		}
+	}
+}
+
+// iterator walks the nodes of a tree in order, keeping only the pending
+// ancestors on its stack, so its memory is bounded by the tree height.
+type iterator[K any, V any] struct {
+	stack   []*Node[K, V]
+	reverse bool
+}
+
+func newIterator[K any, V any](root *Node[K, V], reverse bool) *iterator[K, V] {
+	it := &iterator[K, V]{
+		stack:   make([]*Node[K, V], 0, height(root)),
+		reverse: reverse,
+	}
+	it.pushEdge(root)
+	return it
+}
+
+// pushEdge pushes node and its leftmost (rightmost when reversed) descendants.
+func (it *iterator[K, V]) pushEdge(node *Node[K, V]) {
+	for node != nil {
+		it.stack = append(it.stack, node)
+		if it.reverse {
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+}
+
+func (it *iterator[K, V]) next() *Node[K, V] {
+	if len(it.stack) == 0 {
+		return nil
+	}
+	node := it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+	if it.reverse {
+		it.pushEdge(node.left)
+	} else {
+		it.pushEdge(node.right)
+	}
+	return node
+}
@@ -0,0 +1,242 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"iter"
+)
+
+// Multi is an ordered multimap that keeps every value added for a key in
+// insertion order. Sizes, ranks and iteration count each value separately.
+// The zero Multi is empty and ordered like the zero Tree.
+type Multi[K cmp.Ordered, V any] struct {
+	tree Tree[K, bucket[V]]
+}
+
+// bucket holds the values of a key and the number of values in the subtree
+// of its node.
+type bucket[V any] struct {
+	vals   []V
+	weight int
+}
+
+func NewMulti[K cmp.Ordered, V any]() *Multi[K, V] {
+	m := &Multi[K, V]{}
+	m.init()
+	return m
+}
+
+// Add appends val to the values of key.
+func (m *Multi[K, V]) Add(key K, val V) {
+	if m == nil {
+		panic("bst: called Add() on a nil multimap")
+	}
+	m.init()
+	m.tree.Update(key, func(old bucket[V], exists bool) (bucket[V], bool) {
+		old.vals = append(old.vals, val)
+		return old, true
+	})
+}
+
+// GetAll returns an iterator over the values of key in insertion order.
+func (m *Multi[K, V]) GetAll(key K) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		if m == nil {
+			return
+		}
+		node := m.tree.Find(key)
+		if node == nil {
+			return
+		}
+		for _, v := range node.data.vals {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// RemoveOne removes the earliest value of key and returns it, if there was one.
+func (m *Multi[K, V]) RemoveOne(key K) (val V, ok bool) {
+	if m == nil {
+		panic("bst: called RemoveOne() on a nil multimap")
+	}
+	m.init()
+	m.tree.Update(key, func(old bucket[V], exists bool) (bucket[V], bool) {
+		if !exists {
+			return old, false
+		}
+		val, ok = old.vals[0], true
+		var zero V
+		old.vals[0] = zero
+		old.vals = old.vals[1:]
+		return old, len(old.vals) > 0
+	})
+	return val, ok
+}
+
+// RemoveAll removes every value of key and returns how many were removed.
+func (m *Multi[K, V]) RemoveAll(key K) int {
+	if m == nil {
+		panic("bst: called RemoveAll() on a nil multimap")
+	}
+	m.init()
+	b, _ := m.tree.Delete(key)
+	return len(b.vals)
+}
+
+// Count returns the number of values of key.
+func (m *Multi[K, V]) Count(key K) int {
+	if m == nil {
+		return 0
+	}
+	if node := m.tree.Find(key); node != nil {
+		return len(node.data.vals)
+	}
+	return 0
+}
+
+// Size returns the total number of values.
+func (m *Multi[K, V]) Size() int {
+	if m == nil {
+		return 0
+	}
+	return weight(m.tree.root)
+}
+
+// Keys returns the distinct keys in ascending order.
+func (m *Multi[K, V]) Keys() []K {
+	if m == nil {
+		return nil
+	}
+	return m.tree.Keys()
+}
+
+// All returns an iterator over every key-value pair in ascending key order.
+// Values of the same key are yielded in insertion order.
+func (m *Multi[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m == nil {
+			return
+		}
+		for k, b := range m.tree.All() {
+			for _, v := range b.vals {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Range returns an iterator over the key-value pairs with lo <= key <= hi.
+func (m *Multi[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m == nil {
+			return
+		}
+		for k, b := range m.tree.Range(lo, hi) {
+			for _, v := range b.vals {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Rank returns the number of values whose keys are less than key.
+func (m *Multi[K, V]) Rank(key K) int {
+	if m == nil || m.tree.root == nil {
+		return 0
+	}
+	return weightedRank(m.tree.root, key, false)
+}
+
+// Select returns the i-th key-value pair in the order of All, counting from 0.
+func (m *Multi[K, V]) Select(i int) (key K, val V) {
+	if m == nil {
+		panic("bst: called Select() on a nil multimap")
+	}
+	if i < 0 || i >= m.Size() {
+		panic("bst: called Select() with invalid index")
+	}
+
+	node := m.tree.root
+	for {
+		lw := weight(node.left)
+		if i < lw {
+			node = node.left
+		} else if i < lw+len(node.data.vals) {
+			return node.key, node.data.vals[i-lw]
+		} else {
+			i -= lw + len(node.data.vals)
+			node = node.right
+		}
+	}
+}
+
+// CountRange returns the number of values whose keys satisfy lo <= key <= hi.
+func (m *Multi[K, V]) CountRange(lo, hi K) int {
+	if m == nil || m.tree.root == nil || cmp.Compare(lo, hi) > 0 {
+		return 0
+	}
+	root := m.tree.root
+	return weightedRank(root, hi, true) - weightedRank(root, lo, false)
+}
+
+// init sets up the value count augmentation of a multimap that was not
+// created with NewMulti.
+func (m *Multi[K, V]) init() {
+	if m.tree.aug == nil {
+		m.tree.aug = weigher[K, V]{}
+	}
+}
+
+// weigher maintains the value counts of the buckets.
+type weigher[K any, V any] struct{}
+
+func (weigher[K, V]) update(node *Node[K, bucket[V]]) {
+	node.data.weight = len(node.data.vals) + weight(node.left) + weight(node.right)
+}
+
+// weight returns the number of values in the subtree.
+func weight[K any, V any](node *Node[K, bucket[V]]) int {
+	if node == nil {
+		return 0
+	}
+	return node.data.weight
+}
+
+func weightedRank[K cmp.Ordered, V any](node *Node[K, bucket[V]], key K, inclusive bool) int {
+	r := 0
+	for node != nil {
+		if c := cmp.Compare(key, node.key); c < 0 || (c == 0 && !inclusive) {
+			node = node.left
+		} else {
+			r += weight(node.left) + len(node.data.vals)
+			node = node.right
+		}
+	}
+	return r
+}
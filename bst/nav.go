@@ -0,0 +1,115 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import "cmp"
+
+// Floor returns the node with the greatest key less than or equal to key.
+func (tree *Tree[K, V]) Floor(key K) *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Floor() on a nil tree")
+	}
+	return floor(tree.root, key, true)
+}
+
+// Ceiling returns the node with the smallest key greater than or equal to key.
+func (tree *Tree[K, V]) Ceiling(key K) *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Ceiling() on a nil tree")
+	}
+	return ceiling(tree.root, key, true)
+}
+
+// Predecessor returns the node with the greatest key strictly less than key.
+func (tree *Tree[K, V]) Predecessor(key K) *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Predecessor() on a nil tree")
+	}
+	return floor(tree.root, key, false)
+}
+
+// Successor returns the node with the smallest key strictly greater than key.
+func (tree *Tree[K, V]) Successor(key K) *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Successor() on a nil tree")
+	}
+	return ceiling(tree.root, key, false)
+}
+
+func (tree *Tree[K, V]) Min() *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Min() on a nil tree")
+	}
+	return getMinNode(tree.root)
+}
+
+func (tree *Tree[K, V]) Max() *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Max() on a nil tree")
+	}
+	return getMaxNode(tree.root)
+}
+
+func floor[K cmp.Ordered, V any](node *Node[K, V], key K, inclusive bool) *Node[K, V] {
+	var best *Node[K, V]
+	for node != nil {
+		c := cmp.Compare(key, node.key)
+		if c == 0 && inclusive {
+			return node
+		}
+		if c > 0 {
+			best = node
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+	return best
+}
+
+func ceiling[K cmp.Ordered, V any](node *Node[K, V], key K, inclusive bool) *Node[K, V] {
+	var best *Node[K, V]
+	for node != nil {
+		c := cmp.Compare(key, node.key)
+		if c == 0 && inclusive {
+			return node
+		}
+		if c < 0 {
+			best = node
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	return best
+}
+
+func getMaxNode[K any, V any](node *Node[K, V]) *Node[K, V] {
+	if node == nil {
+		return nil
+	}
+	curr := node
+	for curr.right != nil {
+		curr = curr.right
+	}
+	return curr
+}
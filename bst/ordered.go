@@ -0,0 +1,48 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import "iter"
+
+// OrderedMap is the set of methods shared by the ordered maps of this module:
+// Tree, FuncTree, RBTree and Augmented here, and the trees of package btree.
+// Lookups return values rather than nodes, so callers can choose the tree
+// that suits their workload without depending on its node type. For that
+// reason the interface has Get in place of Find: Find returns the node type of
+// each tree (*Node, *RBNode or *btree.Node), which no single method signature
+// can cover. Every tree still has its own Find.
+type OrderedMap[K any, V any] interface {
+	Insert(key K, data V)
+	Get(key K) (V, bool)
+	Remove(key K)
+	Size() int
+	Keys() []K
+	All() iter.Seq2[K, V]
+	Range(lo, hi K) iter.Seq2[K, V]
+}
+
+var (
+	_ OrderedMap[int, int] = (*Tree[int, int])(nil)
+	_ OrderedMap[int, int] = (*FuncTree[int, int])(nil)
+	_ OrderedMap[int, int] = (*RBTree[int, int])(nil)
+	_ OrderedMap[int, int] = (*Augmented[int, int, int])(nil)
+)
@@ -0,0 +1,232 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"iter"
+)
+
+// Persistent is an immutable AVL tree. Every modification returns a new tree
+// that shares all unchanged nodes with the original, so a Persistent value is
+// a snapshot that can be copied in O(1) and read from any number of goroutines
+// without locking. The zero Persistent is an empty tree ordered like the zero Tree.
+type Persistent[K cmp.Ordered, V any] struct {
+	root *Node[K, V]
+}
+
+func NewPersistent[K cmp.Ordered, V any]() Persistent[K, V] {
+	return Persistent[K, V]{}
+}
+
+// Insert returns a tree with key added. The tree is returned unchanged if key is already present.
+func (p Persistent[K, V]) Insert(key K, data V) Persistent[K, V] {
+	p.root = insertCopy(p.root, key, data, false)
+	p.check()
+	return p
+}
+
+// Put returns a tree in which key is set to data.
+func (p Persistent[K, V]) Put(key K, data V) Persistent[K, V] {
+	p.root = insertCopy(p.root, key, data, true)
+	p.check()
+	return p
+}
+
+// Remove returns a tree without key.
+func (p Persistent[K, V]) Remove(key K) Persistent[K, V] {
+	p.root = removeCopy(p.root, key)
+	p.check()
+	return p
+}
+
+func (p Persistent[K, V]) Find(key K) *Node[K, V] {
+	return find(p.root, key)
+}
+
+func (p Persistent[K, V]) Height() int {
+	return height(p.root)
+}
+
+func (p Persistent[K, V]) Size() int {
+	return getSize(p.root)
+}
+
+func (p Persistent[K, V]) Keys() []K {
+	keys := make([]K, 0, getSize(p.root))
+	for k := range p.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (p Persistent[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		walk(p.root, false, yield)
+	}
+}
+
+func (p Persistent[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if p.root == nil {
+			return
+		}
+		walkRange(p.root, lo, hi, yield)
+	}
+}
+
+func (p Persistent[K, V]) check() {
+	if debug {
+		if err := validate(p.root, cmp.Compare[K]); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// insertCopy is the path-copying counterpart of insert. Nodes of the original
+// tree are never modified; if nothing changes, node itself is returned.
+func insertCopy[K cmp.Ordered, V any](node *Node[K, V], key K, data V, replace bool) *Node[K, V] {
+	if node == nil {
+		return &Node[K, V]{key: key, height: 1, size: 1, data: data}
+	}
+
+	c := cmp.Compare(key, node.key)
+	if c == 0 {
+		if !replace {
+			return node
+		}
+		n := cloneNode(node)
+		n.data = data
+		return n
+	}
+
+	var n *Node[K, V]
+	if c < 0 {
+		left := insertCopy(node.left, key, data, replace)
+		if left == node.left {
+			return node
+		}
+		n = cloneNode(node)
+		n.left = left
+	} else {
+		right := insertCopy(node.right, key, data, replace)
+		if right == node.right {
+			return node
+		}
+		n = cloneNode(node)
+		n.right = right
+	}
+
+	return rebalanceCopy(n)
+}
+
+// removeCopy is the path-copying counterpart of remove.
+func removeCopy[K cmp.Ordered, V any](node *Node[K, V], key K) *Node[K, V] {
+	if node == nil {
+		return nil
+	}
+
+	var n *Node[K, V]
+	if c := cmp.Compare(key, node.key); c < 0 {
+		left := removeCopy(node.left, key)
+		if left == node.left {
+			return node
+		}
+		n = cloneNode(node)
+		n.left = left
+	} else if c > 0 {
+		right := removeCopy(node.right, key)
+		if right == node.right {
+			return node
+		}
+		n = cloneNode(node)
+		n.right = right
+	} else {
+		if node.left == nil {
+			return node.right
+		}
+		if node.right == nil {
+			return node.left
+		}
+		temp := getMinNode(node.right)
+		n = cloneNode(node)
+		n.key = temp.key
+		n.data = temp.data
+		n.right = removeCopy(node.right, temp.key)
+	}
+
+	return rebalanceCopy(n)
+}
+
+// rebalanceCopy restores the AVL property of node, which must be a fresh copy
+// owned by the caller. Any child lifted by a rotation is copied first.
+func rebalanceCopy[K any, V any](node *Node[K, V]) *Node[K, V] {
+	updateCopy(node)
+	balance := getBalance(node)
+
+	if balance > 1 {
+		if getBalance(node.left) < 0 {
+			node.left = rotateLeftCopy(cloneNode(node.left))
+		}
+		return rotateRightCopy(node)
+	}
+	if balance < -1 {
+		if getBalance(node.right) > 0 {
+			node.right = rotateRightCopy(cloneNode(node.right))
+		}
+		return rotateLeftCopy(node)
+	}
+
+	return node
+}
+
+func rotateRightCopy[K any, V any](node *Node[K, V]) *Node[K, V] {
+	newRoot := cloneNode(node.left)
+	node.left = newRoot.right
+	newRoot.right = node
+
+	updateCopy(node)
+	updateCopy(newRoot)
+
+	return newRoot
+}
+
+func rotateLeftCopy[K any, V any](node *Node[K, V]) *Node[K, V] {
+	newRoot := cloneNode(node.right)
+	node.right = newRoot.left
+	newRoot.left = node
+
+	updateCopy(node)
+	updateCopy(newRoot)
+
+	return newRoot
+}
+
+func updateCopy[K any, V any](node *Node[K, V]) {
+	node.height = 1 + max(height(node.left), height(node.right))
+	node.size = 1 + getSize(node.left) + getSize(node.right)
+}
+
+func cloneNode[K any, V any](node *Node[K, V]) *Node[K, V] {
+	n := *node
+	return &n
+}
@@ -0,0 +1,194 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"iter"
+)
+
+// PopMin removes the entry with the smallest key and returns it, if the tree
+// is not empty.
+func (tree *Tree[K, V]) PopMin() (key K, val V, ok bool) {
+	if tree == nil {
+		panic("bst: called PopMin() on a nil tree")
+	}
+	if tree.root == nil {
+		return key, val, false
+	}
+
+	var min *Node[K, V]
+	tree.root, min = tree.removeMin(tree.root)
+	tree.size--
+	tree.mods++
+	tree.stats.removed(1)
+	tree.check()
+	return min.key, min.data, true
+}
+
+// PopMax removes the entry with the greatest key and returns it, if the tree
+// is not empty.
+func (tree *Tree[K, V]) PopMax() (key K, val V, ok bool) {
+	if tree == nil {
+		panic("bst: called PopMax() on a nil tree")
+	}
+	if tree.root == nil {
+		return key, val, false
+	}
+
+	var max *Node[K, V]
+	tree.root, max = tree.removeMax(tree.root)
+	tree.size--
+	tree.mods++
+	tree.stats.removed(1)
+	tree.check()
+	return max.key, max.data, true
+}
+
+// removeMax detaches the node holding the greatest key of the subtree.
+func (tree *avl[K, V]) removeMax(node *Node[K, V]) (root, max *Node[K, V]) {
+	if node.right == nil {
+		left := node.left
+		node.left = nil
+		tree.update(node)
+		return left, node
+	}
+	node.right, max = tree.removeMax(node.right)
+	return tree.rebalance(node), max
+}
+
+// Retain selects which keys a TopK keeps once it is full.
+type Retain int
+
+const (
+	// RetainLargest keeps the greatest keys and evicts the smallest one.
+	RetainLargest Retain = iota
+	// RetainSmallest keeps the smallest keys and evicts the greatest one.
+	RetainSmallest
+)
+
+// TopK is a tree capped at a fixed number of entries. Inserting into a full
+// TopK evicts the smallest or the greatest key, depending on its Retain mode.
+// The zero TopK has no capacity; use NewTopK.
+type TopK[K cmp.Ordered, V any] struct {
+	tree   Tree[K, V]
+	k      int
+	retain Retain
+}
+
+func NewTopK[K cmp.Ordered, V any](k int, retain Retain) *TopK[K, V] {
+	if k <= 0 {
+		panic("bst: called NewTopK() with a non-positive capacity")
+	}
+	return &TopK[K, V]{k: k, retain: retain}
+}
+
+// Insert sets the value for key and evicts an entry if the capacity is
+// exceeded. The evicted entry, which may be the one just inserted, is returned.
+func (t *TopK[K, V]) Insert(key K, val V) (evictedKey K, evictedVal V, evicted bool) {
+	if t == nil {
+		panic("bst: called Insert() on a nil TopK")
+	}
+	if t.k == 0 {
+		panic("bst: called Insert() on a TopK not created by NewTopK()")
+	}
+
+	if t.tree.size == t.k && t.tree.Find(key) == nil {
+		if t.retain == RetainLargest && cmp.Compare(key, t.tree.Min().key) < 0 ||
+			t.retain == RetainSmallest && cmp.Compare(key, t.tree.Max().key) > 0 {
+			return key, val, true
+		}
+	}
+
+	t.tree.Put(key, val)
+	if t.tree.size <= t.k {
+		return evictedKey, evictedVal, false
+	}
+	if t.retain == RetainLargest {
+		return t.tree.PopMin()
+	}
+	return t.tree.PopMax()
+}
+
+func (t *TopK[K, V]) Find(key K) *Node[K, V] {
+	if t == nil {
+		panic("bst: called Find() on a nil TopK")
+	}
+	return t.tree.Find(key)
+}
+
+func (t *TopK[K, V]) Remove(key K) {
+	if t == nil {
+		panic("bst: called Remove() on a nil TopK")
+	}
+	t.tree.Remove(key)
+}
+
+func (t *TopK[K, V]) Min() *Node[K, V] {
+	if t == nil {
+		panic("bst: called Min() on a nil TopK")
+	}
+	return t.tree.Min()
+}
+
+func (t *TopK[K, V]) Max() *Node[K, V] {
+	if t == nil {
+		panic("bst: called Max() on a nil TopK")
+	}
+	return t.tree.Max()
+}
+
+func (t *TopK[K, V]) Size() int {
+	if t == nil {
+		return 0
+	}
+	return t.tree.size
+}
+
+// Cap returns the maximum number of entries.
+func (t *TopK[K, V]) Cap() int {
+	if t == nil {
+		return 0
+	}
+	return t.k
+}
+
+func (t *TopK[K, V]) Keys() []K {
+	if t == nil {
+		return nil
+	}
+	return t.tree.Keys()
+}
+
+func (t *TopK[K, V]) All() iter.Seq2[K, V] {
+	if t == nil {
+		return func(yield func(K, V) bool) {}
+	}
+	return t.tree.All()
+}
+
+func (t *TopK[K, V]) Backward() iter.Seq2[K, V] {
+	if t == nil {
+		return func(yield func(K, V) bool) {}
+	}
+	return t.tree.Backward()
+}
@@ -0,0 +1,83 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import "cmp"
+
+// Rank returns the number of keys in the tree that are less than key.
+func (tree *Tree[K, V]) Rank(key K) int {
+	if tree == nil {
+		return 0
+	}
+	return rank(tree.root, key, false)
+}
+
+// Select returns the node holding the i-th smallest key, counting from 0.
+func (tree *Tree[K, V]) Select(i int) *Node[K, V] {
+	if tree == nil {
+		panic("bst: called Select() on a nil tree")
+	}
+	if i < 0 || i >= getSize(tree.root) {
+		panic("bst: called Select() with invalid index")
+	}
+	return selectNode(tree.root, i)
+}
+
+// CountRange returns the number of keys with lo <= key <= hi.
+func (tree *Tree[K, V]) CountRange(lo, hi K) int {
+	if tree == nil || tree.root == nil {
+		return 0
+	}
+	if cmp.Compare(lo, hi) > 0 {
+		return 0
+	}
+	return rank(tree.root, hi, true) - rank(tree.root, lo, false)
+}
+
+// rank counts the keys below key, including key itself when inclusive is set.
+func rank[K cmp.Ordered, V any](node *Node[K, V], key K, inclusive bool) int {
+	r := 0
+	for node != nil {
+		if c := cmp.Compare(key, node.key); c < 0 || (c == 0 && !inclusive) {
+			node = node.left
+		} else {
+			r += getSize(node.left) + 1
+			node = node.right
+		}
+	}
+	return r
+}
+
+func selectNode[K any, V any](node *Node[K, V], i int) *Node[K, V] {
+	for node != nil {
+		leftSize := getSize(node.left)
+		if i < leftSize {
+			node = node.left
+		} else if i > leftSize {
+			i -= leftSize + 1
+			node = node.right
+		} else {
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,528 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+)
+
+// RBTree is a red-black tree. It is less strictly balanced than Tree, so
+// lookups may visit a few more nodes, but insertions and removals need at most
+// two and three rotations respectively.
+type RBTree[K cmp.Ordered, V any] struct {
+	root *RBNode[K, V]
+	size int
+	path []*RBNode[K, V]
+}
+
+// RBNode is a node of an RBTree. Unlike Node it carries no height or size,
+// only the color of the node.
+type RBNode[K any, V any] struct {
+	key   K
+	left  *RBNode[K, V]
+	right *RBNode[K, V]
+	red   bool
+	data  V
+}
+
+func (node *RBNode[K, V]) Key() K {
+	return node.key
+}
+
+func (node *RBNode[K, V]) Data() V {
+	return node.data
+}
+
+func (node *RBNode[K, V]) String() string {
+	return fmt.Sprintf("%v", node.key)
+}
+
+// NewRB returns an empty red-black tree ordered by the natural ordering of K.
+// It is equivalent to the zero RBTree.
+func NewRB[K cmp.Ordered, V any]() *RBTree[K, V] {
+	return &RBTree[K, V]{}
+}
+
+func (tree *RBTree[K, V]) Insert(key K, data V) {
+	if tree == nil {
+		panic("bst: called Insert() on a nil tree")
+	}
+	tree.insert(key, data)
+	tree.check()
+}
+
+func (tree *RBTree[K, V]) Find(key K) *RBNode[K, V] {
+	if tree == nil {
+		panic("bst: called Find() on a nil tree")
+	}
+	node := tree.root
+	for node != nil {
+		c := cmp.Compare(key, node.key)
+		if c == 0 {
+			return node
+		}
+		if c < 0 {
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	return nil
+}
+
+// Get returns the value of key and whether it is present.
+func (tree *RBTree[K, V]) Get(key K) (val V, ok bool) {
+	if node := tree.Find(key); node != nil {
+		return node.data, true
+	}
+	return val, false
+}
+
+func (tree *RBTree[K, V]) Remove(key K) {
+	if tree == nil {
+		panic("bst: called Remove() on a nil tree")
+	}
+	tree.remove(key)
+	tree.check()
+}
+
+// Height returns the number of nodes on the longest path from the root to a
+// leaf. Red-black nodes do not store their heights, so unlike Tree.Height it
+// walks the whole tree and takes O(n) time.
+func (tree *RBTree[K, V]) Height() int {
+	if tree == nil {
+		return 0
+	}
+	return depth(tree.root)
+}
+
+func (tree *RBTree[K, V]) Size() int {
+	if tree == nil {
+		return 0
+	}
+	return tree.size
+}
+
+func (tree *RBTree[K, V]) Keys() []K {
+	keys := make([]K, 0, tree.Size())
+	for key := range tree.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// All returns an iterator over the key-value pairs of the tree in ascending key order.
+func (tree *RBTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil {
+			return
+		}
+		rbWalk(tree.root, false, yield)
+	}
+}
+
+// Backward returns an iterator over the key-value pairs of the tree in descending key order.
+func (tree *RBTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil {
+			return
+		}
+		rbWalk(tree.root, true, yield)
+	}
+}
+
+// Range returns an iterator over the key-value pairs with lo <= key <= hi in ascending key order.
+func (tree *RBTree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil || tree.root == nil {
+			return
+		}
+		rbWalkRange(tree.root, lo, hi, yield)
+	}
+}
+
+// Min returns the node with the smallest key, or nil if the tree is empty.
+func (tree *RBTree[K, V]) Min() *RBNode[K, V] {
+	if tree == nil || tree.root == nil {
+		return nil
+	}
+	node := tree.root
+	for node.left != nil {
+		node = node.left
+	}
+	return node
+}
+
+// Max returns the node with the greatest key, or nil if the tree is empty.
+func (tree *RBTree[K, V]) Max() *RBNode[K, V] {
+	if tree == nil || tree.root == nil {
+		return nil
+	}
+	node := tree.root
+	for node.right != nil {
+		node = node.right
+	}
+	return node
+}
+
+// Validate checks the structural invariants of the tree: key ordering, a
+// black root, no red node with a red child and the same number of black
+// nodes on every path from the root to a leaf.
+func (tree *RBTree[K, V]) Validate() error {
+	if tree == nil {
+		return nil
+	}
+	if isRed(tree.root) {
+		return fmt.Errorf("bst: root %v is red", tree.root.key)
+	}
+
+	var path []K
+	count := 0
+
+	var walk func(node, lo, hi *RBNode[K, V]) (int, error)
+	walk = func(node, lo, hi *RBNode[K, V]) (int, error) {
+		if node == nil {
+			return 1, nil
+		}
+		path = append(path, node.key)
+		count++
+
+		if lo != nil && cmp.Compare(node.key, lo.key) <= 0 {
+			return 0, fmt.Errorf("bst: node at path %v: key is not greater than %v", path, lo.key)
+		}
+		if hi != nil && cmp.Compare(node.key, hi.key) >= 0 {
+			return 0, fmt.Errorf("bst: node at path %v: key is not less than %v", path, hi.key)
+		}
+		if node.red && (isRed(node.left) || isRed(node.right)) {
+			return 0, fmt.Errorf("bst: node at path %v: red node has a red child", path)
+		}
+
+		lb, err := walk(node.left, lo, node)
+		if err != nil {
+			return 0, err
+		}
+		rb, err := walk(node.right, node, hi)
+		if err != nil {
+			return 0, err
+		}
+		if lb != rb {
+			return 0, fmt.Errorf("bst: node at path %v: black heights %d and %d differ", path, lb, rb)
+		}
+
+		path = path[:len(path)-1]
+		if !node.red {
+			lb++
+		}
+		return lb, nil
+	}
+	if _, err := walk(tree.root, nil, nil); err != nil {
+		return err
+	}
+	if tree.size != count {
+		return fmt.Errorf("bst: tree size is %d, expected %d", tree.size, count)
+	}
+	return nil
+}
+
+// check validates the tree after a modification when built with the bstdebug tag.
+func (tree *RBTree[K, V]) check() {
+	if debug {
+		if err := tree.Validate(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func isRed[K any, V any](node *RBNode[K, V]) bool {
+	return node != nil && node.red
+}
+
+func depth[K any, V any](node *RBNode[K, V]) int {
+	if node == nil {
+		return 0
+	}
+	return 1 + max(depth(node.left), depth(node.right))
+}
+
+// rbWalk and rbWalkRange are the counterparts of walk and walkRange. The
+// stack holds the pending ancestors, as in iterator.
+
+func rbWalk[K any, V any](root *RBNode[K, V], reverse bool, yield func(K, V) bool) {
+	var stack []*RBNode[K, V]
+	for node := root; node != nil || len(stack) > 0; {
+		for ; node != nil; node = rbChild(node, reverse) {
+			stack = append(stack, node)
+		}
+		node = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !yield(node.key, node.data) {
+			return
+		}
+		node = rbChild(node, !reverse)
+	}
+}
+
+func rbWalkRange[K cmp.Ordered, V any](root *RBNode[K, V], lo, hi K, yield func(K, V) bool) {
+	if cmp.Compare(lo, hi) > 0 {
+		return
+	}
+	var stack []*RBNode[K, V]
+	for node := root; node != nil; {
+		if cmp.Compare(node.key, lo) >= 0 {
+			stack = append(stack, node)
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if cmp.Compare(node.key, hi) > 0 || !yield(node.key, node.data) {
+			return
+		}
+		for node = node.right; node != nil; node = node.left {
+			stack = append(stack, node)
+		}
+	}
+}
+
+// rbChild returns the left child of node, or the right one if right is set.
+func rbChild[K any, V any](node *RBNode[K, V], right bool) *RBNode[K, V] {
+	if right {
+		return node.right
+	}
+	return node.left
+}
+
+// The nodes do not keep parent links, so insert and remove record the
+// ancestors of the node they work on in tree.path, which is reused between
+// calls.
+
+func (tree *RBTree[K, V]) insert(key K, data V) {
+	path := tree.path[:0]
+	node := tree.root
+	for node != nil {
+		c := cmp.Compare(key, node.key)
+		if c == 0 {
+			return
+		}
+		path = append(path, node)
+		if c < 0 {
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+
+	node = &RBNode[K, V]{key: key, data: data, red: true}
+	if len(path) == 0 {
+		tree.root = node
+	} else if parent := path[len(path)-1]; cmp.Compare(key, parent.key) < 0 {
+		parent.left = node
+	} else {
+		parent.right = node
+	}
+	tree.size++
+
+	tree.path = tree.fixInsert(node, path)
+}
+
+// fixInsert restores the red-black properties after node was added as a red
+// leaf below path. It returns path for reuse.
+func (tree *RBTree[K, V]) fixInsert(node *RBNode[K, V], path []*RBNode[K, V]) []*RBNode[K, V] {
+	for len(path) > 1 && path[len(path)-1].red {
+		parent, grand := path[len(path)-1], path[len(path)-2]
+		path = path[:len(path)-2]
+
+		uncle := grand.left
+		if parent == grand.left {
+			uncle = grand.right
+		}
+		if isRed(uncle) {
+			parent.red, uncle.red, grand.red = false, false, true
+			node = grand
+			continue
+		}
+
+		// Bring node, parent and grand into a line, then rotate grand away.
+		if parent == grand.left {
+			if node == parent.right {
+				grand.left = tree.rotateLeft(parent)
+				parent = node
+			}
+			parent.red, grand.red = false, true
+			tree.replace(path, grand, tree.rotateRight(grand))
+		} else {
+			if node == parent.left {
+				grand.right = tree.rotateRight(parent)
+				parent = node
+			}
+			parent.red, grand.red = false, true
+			tree.replace(path, grand, tree.rotateLeft(grand))
+		}
+		break
+	}
+	tree.root.red = false
+	return path[:0]
+}
+
+func (tree *RBTree[K, V]) remove(key K) {
+	path := tree.path[:0]
+	node := tree.root
+	for node != nil {
+		c := cmp.Compare(key, node.key)
+		if c == 0 {
+			break
+		}
+		path = append(path, node)
+		if c < 0 {
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	if node == nil {
+		tree.path = path[:0]
+		return
+	}
+
+	// A node with two children takes the entry of its successor, which is
+	// removed instead.
+	if node.left != nil && node.right != nil {
+		path = append(path, node)
+		succ := node.right
+		for succ.left != nil {
+			path = append(path, succ)
+			succ = succ.left
+		}
+		node.key = succ.key
+		node.data = succ.data
+		node = succ
+	}
+
+	child := node.left
+	if child == nil {
+		child = node.right
+	}
+	left := len(path) > 0 && path[len(path)-1].left == node
+	tree.replace(path, node, child)
+	tree.size--
+
+	switch {
+	case node.red:
+	case isRed(child):
+		child.red = false
+	default:
+		path = tree.fixRemove(child, left, path)
+	}
+	tree.path = path[:0]
+}
+
+// fixRemove restores the red-black properties after a black node was removed
+// from the left (or right) of the last node in path and replaced with node,
+// which may be nil. Its subtree is then one black node short.
+func (tree *RBTree[K, V]) fixRemove(node *RBNode[K, V], left bool, path []*RBNode[K, V]) []*RBNode[K, V] {
+	for len(path) > 0 && !isRed(node) {
+		parent := path[len(path)-1]
+		path = path[:len(path)-1]
+
+		if left {
+			sibling := parent.right
+			if sibling.red {
+				sibling.red, parent.red = false, true
+				tree.replace(path, parent, tree.rotateLeft(parent))
+				path = append(path, sibling)
+				sibling = parent.right
+			}
+			if !isRed(sibling.left) && !isRed(sibling.right) {
+				sibling.red = true
+				node = parent
+				left = len(path) > 0 && path[len(path)-1].left == parent
+				continue
+			}
+			if !isRed(sibling.right) {
+				sibling.left.red, sibling.red = false, true
+				parent.right = tree.rotateRight(sibling)
+				sibling = parent.right
+			}
+			sibling.red, parent.red, sibling.right.red = parent.red, false, false
+			tree.replace(path, parent, tree.rotateLeft(parent))
+		} else {
+			sibling := parent.left
+			if sibling.red {
+				sibling.red, parent.red = false, true
+				tree.replace(path, parent, tree.rotateRight(parent))
+				path = append(path, sibling)
+				sibling = parent.left
+			}
+			if !isRed(sibling.left) && !isRed(sibling.right) {
+				sibling.red = true
+				node = parent
+				left = len(path) > 0 && path[len(path)-1].left == parent
+				continue
+			}
+			if !isRed(sibling.left) {
+				sibling.right.red, sibling.red = false, true
+				parent.left = tree.rotateLeft(sibling)
+				sibling = parent.left
+			}
+			sibling.red, parent.red, sibling.left.red = parent.red, false, false
+			tree.replace(path, parent, tree.rotateRight(parent))
+		}
+		node = tree.root
+		break
+	}
+	if node != nil {
+		node.red = false
+	}
+	return path[:0]
+}
+
+// replace puts node in place of old, the child of the last node in path.
+func (tree *RBTree[K, V]) replace(path []*RBNode[K, V], old, node *RBNode[K, V]) {
+	if len(path) == 0 {
+		tree.root = node
+		return
+	}
+	if parent := path[len(path)-1]; parent.left == old {
+		parent.left = node
+	} else {
+		parent.right = node
+	}
+}
+
+func (tree *RBTree[K, V]) rotateLeft(node *RBNode[K, V]) *RBNode[K, V] {
+	newRoot := node.right
+	node.right = newRoot.left
+	newRoot.left = node
+	return newRoot
+}
+
+func (tree *RBTree[K, V]) rotateRight(node *RBNode[K, V]) *RBNode[K, V] {
+	newRoot := node.left
+	node.left = newRoot.right
+	newRoot.right = node
+	return newRoot
+}
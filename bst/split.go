@@ -0,0 +1,249 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import "cmp"
+
+// Split moves the keys less than key into left and the remaining keys into
+// right, leaving tree empty. Both trees keep the augmentation and observer of
+// tree and collect stats of their own if it does.
+func (tree *Tree[K, V]) Split(key K) (left, right *Tree[K, V]) {
+	if tree == nil {
+		panic("bst: called Split() on a nil tree")
+	}
+
+	l, mid, r := tree.split(tree.root, key)
+	if mid != nil {
+		r = tree.join(nil, mid, r)
+	}
+
+	left = &Tree[K, V]{avl: avl[K, V]{root: l, size: getSize(l), aug: tree.aug}}
+	right = &Tree[K, V]{avl: avl[K, V]{root: r, size: getSize(r), aug: tree.aug}}
+	left.instrument(&tree.avl)
+	right.instrument(&tree.avl)
+	tree.root, tree.size = nil, 0
+	tree.mods++
+	left.check()
+	right.check()
+	return left, right
+}
+
+// Join returns a tree holding the entries of left followed by the entries of
+// right, leaving both of them empty. Every key of left must be less than every
+// key of right. The result uses the augmentation, observer and stats setting
+// of left.
+func Join[K cmp.Ordered, V any](left, right *Tree[K, V]) *Tree[K, V] {
+	if left == nil || right == nil {
+		panic("bst: called Join() with a nil tree")
+	}
+
+	if left.root != nil && right.root != nil &&
+		cmp.Compare(getMaxNode(left.root).key, getMinNode(right.root).key) >= 0 {
+		panic("bst: called Join() with overlapping trees")
+	}
+
+	tree := &Tree[K, V]{avl: avl[K, V]{aug: left.aug}}
+	tree.instrument(&left.avl)
+	tree.root = tree.join2(left.root, right.root)
+	tree.size = getSize(tree.root)
+	left.root, left.size = nil, 0
+	right.root, right.size = nil, 0
+	left.mods++
+	right.mods++
+	tree.check()
+	return tree
+}
+
+// Union moves every entry of other into tree, leaving other empty. Values from
+// other replace the values of keys present in both trees. The union of a tree
+// with itself leaves it unchanged.
+func (tree *Tree[K, V]) Union(other *Tree[K, V]) {
+	if tree == nil || other == nil {
+		panic("bst: called Union() with a nil tree")
+	}
+	if tree == other {
+		return
+	}
+	tree.root = tree.union(tree.root, other.root)
+	tree.size = getSize(tree.root)
+	other.root, other.size = nil, 0
+	tree.mods++
+	other.mods++
+	tree.check()
+}
+
+// Intersection keeps only the keys of tree that are also present in other,
+// leaving other empty. The intersection of a tree with itself leaves it
+// unchanged.
+func (tree *Tree[K, V]) Intersection(other *Tree[K, V]) {
+	if tree == nil || other == nil {
+		panic("bst: called Intersection() with a nil tree")
+	}
+	if tree == other {
+		return
+	}
+	tree.root = tree.intersection(tree.root, other.root)
+	tree.size = getSize(tree.root)
+	other.root, other.size = nil, 0
+	tree.mods++
+	other.mods++
+	tree.check()
+}
+
+// Difference removes from tree every key that is present in other, leaving
+// other empty. The difference of a tree with itself empties it.
+func (tree *Tree[K, V]) Difference(other *Tree[K, V]) {
+	if tree == nil || other == nil {
+		panic("bst: called Difference() with a nil tree")
+	}
+	if tree == other {
+		tree.root, tree.size = nil, 0
+		tree.mods++
+		return
+	}
+	tree.root = tree.difference(tree.root, other.root)
+	tree.size = getSize(tree.root)
+	other.root, other.size = nil, 0
+	tree.mods++
+	other.mods++
+	tree.check()
+}
+
+// split divides the subtree rooted at node into the keys less than key, the
+// detached node holding key, if any, and the keys greater than key.
+func (tree *Tree[K, V]) split(node *Node[K, V], key K) (l, mid, r *Node[K, V]) {
+	if node == nil {
+		return nil, nil, nil
+	}
+
+	left, right := node.left, node.right
+	if c := cmp.Compare(key, node.key); c < 0 {
+		l, mid, r = tree.split(left, key)
+		return l, mid, tree.join(r, node, right)
+	} else if c > 0 {
+		l, mid, r = tree.split(right, key)
+		return tree.join(left, node, l), mid, r
+	}
+
+	node.left, node.right = nil, nil
+	tree.update(node)
+	return left, node, right
+}
+
+// join links l and r under mid. Every key of l must be less than mid.key and
+// mid.key must be less than every key of r.
+func (tree *avl[K, V]) join(l, mid, r *Node[K, V]) *Node[K, V] {
+	if height(l) > height(r)+1 {
+		return tree.joinRight(l, mid, r)
+	}
+	if height(r) > height(l)+1 {
+		return tree.joinLeft(l, mid, r)
+	}
+	mid.left, mid.right = l, r
+	tree.update(mid)
+	return mid
+}
+
+// joinRight descends the right spine of the taller tree l until the subtree
+// is no more than one level taller than r, links it there and rebalances on
+// the way back up.
+func (tree *avl[K, V]) joinRight(l, mid, r *Node[K, V]) *Node[K, V] {
+	if height(l) <= height(r)+1 {
+		return tree.join(l, mid, r)
+	}
+	l.right = tree.joinRight(l.right, mid, r)
+	return tree.rebalance(l)
+}
+
+func (tree *avl[K, V]) joinLeft(l, mid, r *Node[K, V]) *Node[K, V] {
+	if height(r) <= height(l)+1 {
+		return tree.join(l, mid, r)
+	}
+	r.left = tree.joinLeft(l, mid, r.left)
+	return tree.rebalance(r)
+}
+
+// join2 links l and r without a middle node by lifting the minimum of r.
+func (tree *avl[K, V]) join2(l, r *Node[K, V]) *Node[K, V] {
+	if r == nil {
+		return l
+	}
+	r, mid := tree.removeMin(r)
+	return tree.join(l, mid, r)
+}
+
+// removeMin detaches the node holding the smallest key of the subtree.
+func (tree *avl[K, V]) removeMin(node *Node[K, V]) (root, min *Node[K, V]) {
+	if node.left == nil {
+		right := node.right
+		node.right = nil
+		tree.update(node)
+		return right, node
+	}
+	node.left, min = tree.removeMin(node.left)
+	return tree.rebalance(node), min
+}
+
+func (tree *Tree[K, V]) union(a, b *Node[K, V]) *Node[K, V] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	left, right := a.left, a.right
+	bl, dup, br := tree.split(b, a.key)
+	if dup != nil {
+		a.data = dup.data
+	}
+	l := tree.union(left, bl)
+	r := tree.union(right, br)
+	return tree.join(l, a, r)
+}
+
+func (tree *Tree[K, V]) intersection(a, b *Node[K, V]) *Node[K, V] {
+	if a == nil || b == nil {
+		return nil
+	}
+
+	left, right := a.left, a.right
+	bl, dup, br := tree.split(b, a.key)
+	l := tree.intersection(left, bl)
+	r := tree.intersection(right, br)
+	if dup != nil {
+		return tree.join(l, a, r)
+	}
+	return tree.join2(l, r)
+}
+
+func (tree *Tree[K, V]) difference(a, b *Node[K, V]) *Node[K, V] {
+	if a == nil || b == nil {
+		return a
+	}
+
+	left, right := b.left, b.right
+	al, _, ar := tree.split(a, b.key)
+	l := tree.difference(al, left)
+	r := tree.difference(ar, right)
+	return tree.join2(l, r)
+}
@@ -0,0 +1,182 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"sync/atomic"
+)
+
+// Direction is the direction of a rotation.
+type Direction int
+
+const (
+	Left Direction = iota
+	Right
+)
+
+func (d Direction) String() string {
+	if d == Left {
+		return "left"
+	}
+	return "right"
+}
+
+// Observer is notified of the rotations made while rebalancing a tree. A
+// double rotation is reported as its two rotations, both with double set.
+type Observer interface {
+	Rotated(dir Direction, double bool)
+}
+
+// Stats is a snapshot of the counters of a tree. Inserts and Removes count the
+// keys added and removed one at a time by Insert, Put, GetOrInsert, Update,
+// Remove, Delete, PopMin, PopMax, DeleteRange, DeleteFunc and Cursor.Delete.
+// Operations that replace or move whole trees, such as Split, Join, Union,
+// Intersection, Difference and the Unmarshal methods, are not counted. Search
+// depths are measured by the lookups of Find, Get, Put, GetOrInsert, Update
+// and Delete and count the nodes visited.
+type Stats struct {
+	Inserts         int
+	Removes         int
+	SingleRotations int
+	DoubleRotations int
+	Searches        int
+	AvgSearchDepth  float64
+	MaxSearchDepth  int
+}
+
+// stats holds the counters behind Stats. Find updates them while holding only
+// a read lock in SyncTree, so they are atomic.
+type stats struct {
+	inserts      atomic.Int64
+	removes      atomic.Int64
+	singles      atomic.Int64
+	doubleHalves atomic.Int64
+	searches     atomic.Int64
+	depthSum     atomic.Int64
+	maxDepth     atomic.Int64
+}
+
+// EnableStats starts collecting the counters reported by Stats. It must be
+// called before the tree is shared between goroutines.
+func (tree *Tree[K, V]) EnableStats() {
+	if tree == nil {
+		panic("bst: called EnableStats() on a nil tree")
+	}
+	if tree.stats == nil {
+		tree.stats = &stats{}
+	}
+}
+
+// Stats returns the counters collected since EnableStats was called, or zero
+// Stats if it was not.
+func (tree *Tree[K, V]) Stats() Stats {
+	if tree == nil || tree.stats == nil {
+		return Stats{}
+	}
+	s := tree.stats
+	st := Stats{
+		Inserts:         int(s.inserts.Load()),
+		Removes:         int(s.removes.Load()),
+		SingleRotations: int(s.singles.Load()),
+		DoubleRotations: int(s.doubleHalves.Load() / 2),
+		Searches:        int(s.searches.Load()),
+		MaxSearchDepth:  int(s.maxDepth.Load()),
+	}
+	if st.Searches > 0 {
+		st.AvgSearchDepth = float64(s.depthSum.Load()) / float64(st.Searches)
+	}
+	return st
+}
+
+// instrument gives a tree derived from another one by Split, Join or Clone
+// the observer of from and, if from collects stats, counters of its own.
+func (tree *avl[K, V]) instrument(from *avl[K, V]) {
+	tree.observer = from.observer
+	if from.stats != nil {
+		tree.stats = &stats{}
+	}
+}
+
+// SetObserver sets the observer notified of rotations, nil removes it. It must
+// be called before the tree is shared between goroutines.
+func (tree *Tree[K, V]) SetObserver(obs Observer) {
+	if tree == nil {
+		panic("bst: called SetObserver() on a nil tree")
+	}
+	tree.observer = obs
+}
+
+func (tree *avl[K, V]) rotated(dir Direction, double bool) {
+	if s := tree.stats; s != nil {
+		if double {
+			s.doubleHalves.Add(1)
+		} else {
+			s.singles.Add(1)
+		}
+	}
+	if tree.observer != nil {
+		tree.observer.Rotated(dir, double)
+	}
+}
+
+func (s *stats) inserted() {
+	if s != nil {
+		s.inserts.Add(1)
+	}
+}
+
+func (s *stats) removed(n int) {
+	if s != nil {
+		s.removes.Add(int64(n))
+	}
+}
+
+func (s *stats) searched(depth int) {
+	s.searches.Add(1)
+	s.depthSum.Add(int64(depth))
+	for {
+		max := s.maxDepth.Load()
+		if int64(depth) <= max || s.maxDepth.CompareAndSwap(max, int64(depth)) {
+			return
+		}
+	}
+}
+
+// findCounted is find that records the search depth in s.
+func findCounted[K cmp.Ordered, V any](node *Node[K, V], key K, s *stats) *Node[K, V] {
+	depth := 0
+	for node != nil {
+		depth++
+		c := cmp.Compare(key, node.key)
+		if c == 0 {
+			break
+		}
+		if c < 0 {
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	s.searched(depth)
+	return node
+}
@@ -0,0 +1,249 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"iter"
+	"math/rand/v2"
+	"sync"
+)
+
+// SyncTree is an ordered map that is safe for concurrent use. It guards a Tree
+// with a reader/writer lock and never lets nodes escape the lock: lookups
+// return copies of values and iteration works on a snapshot taken under the
+// read lock. The zero SyncTree is empty and ordered like the zero Tree.
+type SyncTree[K cmp.Ordered, V any] struct {
+	mu      sync.RWMutex
+	striped *stripedRWMutex
+	tree    Tree[K, V]
+}
+
+// NewSync returns a SyncTree that takes over the entries and the augmentation
+// of tree, leaving tree empty.
+func NewSync[K cmp.Ordered, V any](tree *Tree[K, V]) *SyncTree[K, V] {
+	s := &SyncTree[K, V]{}
+	if tree != nil {
+		s.tree = *tree
+		*tree = Tree[K, V]{avl: avl[K, V]{aug: tree.aug}}
+	}
+	return s
+}
+
+// NewStripedSync is like NewSync, but spreads readers over the given number of
+// lock stripes so that they do not contend on a single reader count. Writers
+// have to acquire every stripe, which makes them slower, so it is meant for
+// read-mostly workloads.
+func NewStripedSync[K cmp.Ordered, V any](tree *Tree[K, V], stripes int) *SyncTree[K, V] {
+	if stripes <= 0 {
+		panic("bst: called NewStripedSync() with a non-positive number of stripes")
+	}
+	s := NewSync(tree)
+	s.striped = &stripedRWMutex{stripes: make([]paddedRWMutex, stripes)}
+	return s
+}
+
+// Load returns the value stored for key, if any.
+func (s *SyncTree[K, V]) Load(key K) (val V, ok bool) {
+	defer s.runlock(s.rlock())
+	if node := s.tree.Find(key); node != nil {
+		return node.data, true
+	}
+	return val, false
+}
+
+// Store sets the value for key.
+func (s *SyncTree[K, V]) Store(key K, val V) {
+	s.lock()
+	defer s.unlock()
+	s.tree.Put(key, val)
+}
+
+// LoadOrStore returns the existing value for key if present. Otherwise it
+// stores and returns val. The loaded result is true if the value was present.
+func (s *SyncTree[K, V]) LoadOrStore(key K, val V) (actual V, loaded bool) {
+	s.lock()
+	defer s.unlock()
+	return s.tree.GetOrInsert(key, val)
+}
+
+// LoadAndDelete removes key and returns its previous value, if any.
+func (s *SyncTree[K, V]) LoadAndDelete(key K) (val V, loaded bool) {
+	s.lock()
+	defer s.unlock()
+	return s.tree.Delete(key)
+}
+
+func (s *SyncTree[K, V]) Delete(key K) {
+	s.lock()
+	defer s.unlock()
+	s.tree.Remove(key)
+}
+
+// CompareAndSwap stores new for key if its current value is equal to old. The
+// values are compared with ==, so V must hold comparable values, otherwise
+// CompareAndSwap panics.
+func (s *SyncTree[K, V]) CompareAndSwap(key K, old, new V) bool {
+	s.lock()
+	defer s.unlock()
+	node := s.tree.Find(key)
+	if node == nil || any(node.data) != any(old) {
+		return false
+	}
+	s.tree.Put(key, new)
+	return true
+}
+
+// CompareAndDelete removes key if its current value is equal to old. The
+// values are compared as in CompareAndSwap.
+func (s *SyncTree[K, V]) CompareAndDelete(key K, old V) bool {
+	s.lock()
+	defer s.unlock()
+	node := s.tree.Find(key)
+	if node == nil || any(node.data) != any(old) {
+		return false
+	}
+	s.tree.Remove(key)
+	return true
+}
+
+// Update atomically applies fn to the value of key, as Tree.Update does. fn
+// is called with the lock held and must not use s.
+func (s *SyncTree[K, V]) Update(key K, fn func(old V, exists bool) (val V, keep bool)) {
+	s.lock()
+	defer s.unlock()
+	s.tree.Update(key, fn)
+}
+
+func (s *SyncTree[K, V]) Size() int {
+	defer s.runlock(s.rlock())
+	return s.tree.Size()
+}
+
+func (s *SyncTree[K, V]) Keys() []K {
+	defer s.runlock(s.rlock())
+	return s.tree.Keys()
+}
+
+// All returns an iterator over a snapshot of every key-value pair in ascending
+// key order. The snapshot is taken when iteration starts; the lock is not held
+// while the loop body runs, so it may modify s.
+func (s *SyncTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		keys, vals := s.snapshot(s.tree.All)
+		for i := range keys {
+			if !yield(keys[i], vals[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Range is like All, but only includes the keys with lo <= key <= hi.
+func (s *SyncTree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		keys, vals := s.snapshot(func() iter.Seq2[K, V] { return s.tree.Range(lo, hi) })
+		for i := range keys {
+			if !yield(keys[i], vals[i]) {
+				return
+			}
+		}
+	}
+}
+
+func (s *SyncTree[K, V]) snapshot(seq func() iter.Seq2[K, V]) ([]K, []V) {
+	defer s.runlock(s.rlock())
+	var keys []K
+	var vals []V
+	for k, v := range seq() {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	return keys, vals
+}
+
+func (s *SyncTree[K, V]) lock() {
+	if s.striped != nil {
+		s.striped.Lock()
+	} else {
+		s.mu.Lock()
+	}
+}
+
+func (s *SyncTree[K, V]) unlock() {
+	if s.striped != nil {
+		s.striped.Unlock()
+	} else {
+		s.mu.Unlock()
+	}
+}
+
+// rlock acquires a read lock and returns the stripe that has to be passed to runlock.
+func (s *SyncTree[K, V]) rlock() int {
+	if s.striped != nil {
+		return s.striped.RLock()
+	}
+	s.mu.RLock()
+	return 0
+}
+
+func (s *SyncTree[K, V]) runlock(stripe int) {
+	if s.striped != nil {
+		s.striped.RUnlock(stripe)
+	} else {
+		s.mu.RUnlock()
+	}
+}
+
+// stripedRWMutex is a reader/writer lock made of several independent locks.
+// A reader locks a single random stripe and a writer locks all of them.
+type stripedRWMutex struct {
+	stripes []paddedRWMutex
+}
+
+// paddedRWMutex occupies a cache line of its own to avoid false sharing.
+type paddedRWMutex struct {
+	sync.RWMutex
+	_ [40]byte
+}
+
+func (m *stripedRWMutex) RLock() int {
+	i := rand.IntN(len(m.stripes))
+	m.stripes[i].RLock()
+	return i
+}
+
+func (m *stripedRWMutex) RUnlock(i int) {
+	m.stripes[i].RUnlock()
+}
+
+func (m *stripedRWMutex) Lock() {
+	for i := range m.stripes {
+		m.stripes[i].Lock()
+	}
+}
+
+func (m *stripedRWMutex) Unlock() {
+	for i := len(m.stripes) - 1; i >= 0; i-- {
+		m.stripes[i].Unlock()
+	}
+}
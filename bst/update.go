@@ -0,0 +1,93 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+// Put sets the value for key, inserting it if missing. It returns the previous
+// value and whether one was replaced.
+func (tree *Tree[K, V]) Put(key K, val V) (old V, replaced bool) {
+	if tree == nil {
+		panic("bst: called Put() on a nil tree")
+	}
+	if node := tree.lookup(key); node != nil {
+		old, node.data = node.data, val
+		tree.refresh(tree.root, key)
+		return old, true
+	}
+	tree.root = tree.insert(tree.root, key, val)
+	tree.check()
+	return old, false
+}
+
+// GetOrInsert returns the existing value for key if present. Otherwise it
+// inserts val and returns it. The loaded result is true if the value was present.
+func (tree *Tree[K, V]) GetOrInsert(key K, val V) (actual V, loaded bool) {
+	if tree == nil {
+		panic("bst: called GetOrInsert() on a nil tree")
+	}
+	if node := tree.lookup(key); node != nil {
+		return node.data, true
+	}
+	tree.root = tree.insert(tree.root, key, val)
+	tree.check()
+	return val, false
+}
+
+// Update calls fn with the current value for key and whether it exists. The key
+// is set to the returned value if keep is true, and removed otherwise.
+func (tree *Tree[K, V]) Update(key K, fn func(old V, exists bool) (val V, keep bool)) {
+	if tree == nil {
+		panic("bst: called Update() on a nil tree")
+	}
+
+	var old V
+	node := tree.lookup(key)
+	if node != nil {
+		old = node.data
+	}
+
+	val, keep := fn(old, node != nil)
+	switch {
+	case !keep && node != nil:
+		tree.root = tree.remove(tree.root, key)
+	case keep && node != nil:
+		node.data = val
+		tree.refresh(tree.root, key)
+	case keep:
+		tree.root = tree.insert(tree.root, key, val)
+	}
+	tree.check()
+}
+
+// Delete removes key from the tree and returns its value, if it was present.
+func (tree *Tree[K, V]) Delete(key K) (val V, ok bool) {
+	if tree == nil {
+		panic("bst: called Delete() on a nil tree")
+	}
+	node := tree.lookup(key)
+	if node == nil {
+		return val, false
+	}
+	val = node.data
+	tree.root = tree.remove(tree.root, key)
+	tree.check()
+	return val, true
+}
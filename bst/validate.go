@@ -0,0 +1,95 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bst
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// Validate checks the structural invariants of the tree: key ordering, AVL
+// balance and the stored height and size of every node. The returned error
+// describes the first violation and the path of keys leading to it.
+func (tree *Tree[K, V]) Validate() error {
+	if tree == nil || tree.root == nil {
+		if tree != nil && tree.size != 0 {
+			return fmt.Errorf("bst: empty tree has size %d", tree.size)
+		}
+		return nil
+	}
+	if err := validate(tree.root, cmp.Compare[K]); err != nil {
+		return err
+	}
+	if tree.size != tree.root.size {
+		return fmt.Errorf("bst: tree size is %d, expected %d", tree.size, tree.root.size)
+	}
+	return nil
+}
+
+// check validates the tree after a modification when built with the bstdebug tag.
+func (tree *Tree[K, V]) check() {
+	if debug {
+		if err := tree.Validate(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func validate[K any, V any](root *Node[K, V], cmp func(a, b K) int) error {
+	var path []K
+
+	var walk func(node, lo, hi *Node[K, V]) error
+	walk = func(node, lo, hi *Node[K, V]) error {
+		if node == nil {
+			return nil
+		}
+		path = append(path, node.key)
+
+		if lo != nil && cmp(node.key, lo.key) <= 0 {
+			return fmt.Errorf("bst: node at path %v: key is not greater than %v", path, lo.key)
+		}
+		if hi != nil && cmp(node.key, hi.key) >= 0 {
+			return fmt.Errorf("bst: node at path %v: key is not less than %v", path, hi.key)
+		}
+		if err := walk(node.left, lo, node); err != nil {
+			return err
+		}
+		if err := walk(node.right, node, hi); err != nil {
+			return err
+		}
+
+		if h := 1 + max(height(node.left), height(node.right)); node.height != h {
+			return fmt.Errorf("bst: node at path %v: height is %d, expected %d", path, node.height, h)
+		}
+		if b := getBalance(node); b < -1 || b > 1 {
+			return fmt.Errorf("bst: node at path %v: balance factor is %d", path, b)
+		}
+		if s := 1 + getSize(node.left) + getSize(node.right); node.size != s {
+			return fmt.Errorf("bst: node at path %v: size is %d, expected %d", path, node.size, s)
+		}
+
+		path = path[:len(path)-1]
+		return nil
+	}
+
+	return walk(root, nil, nil)
+}
@@ -0,0 +1,624 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+// Package btree implements B-trees: Tree, a disk-backed B+tree, and Map, an
+// in-memory B-tree.
+//
+// A Tree is stored in a single file of fixed-size pages. Every Insert, Put
+// and Remove is written through a journal before it returns, so the file
+// survives a crash in either its old or its new state. Neither type is safe
+// for concurrent use.
+package btree
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"slices"
+)
+
+type Options[K any, V any] struct {
+	// PageSize is the size of a page in bytes when a new file is created, it
+	// defaults to 4096. Existing files keep the page size they were created with.
+	PageSize int
+
+	// NoSync skips flushing writes to stable storage. It speeds up bulk loads,
+	// but a crash of the operating system may then corrupt the file.
+	NoSync bool
+
+	// CacheSize is the number of decoded pages kept in memory, it defaults to 256.
+	CacheSize int
+
+	// KeyCodec and ValueCodec encode keys and values. By default strings, byte
+	// slices and numbers are stored in a compact binary form and other types
+	// with encoding/gob.
+	KeyCodec   Codec[K]
+	ValueCodec Codec[V]
+}
+
+// Tree is an ordered map stored in a file. Methods that fail because of an
+// I/O error or a corrupt file record the error, which is then reported by Err
+// and Close, and every later call does nothing.
+type Tree[K cmp.Ordered, V any] struct {
+	pager *pager
+	meta  meta
+	keys  Codec[K]
+	vals  Codec[V]
+	cache *cache[K]
+	dirty map[pgid]*node[K]
+	err   error
+}
+
+// Open opens the tree stored in the file at path, creating the file if it
+// does not exist. A nil opts uses the defaults.
+func Open[K cmp.Ordered, V any](path string, opts *Options[K, V]) (*Tree[K, V], error) {
+	if opts == nil {
+		opts = &Options[K, V]{}
+	}
+	pageSize := cmp.Or(opts.PageSize, defaultPageSize)
+	if pageSize < minPageSize || pageSize > maxPageSize {
+		return nil, fmt.Errorf("btree: page size %d is not in [%d, %d]", pageSize, minPageSize, maxPageSize)
+	}
+	cacheSize := opts.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = defaultCacheSize
+	}
+
+	p, m, err := openPager(path, pageSize, opts.NoSync)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Tree[K, V]{
+		pager: p,
+		meta:  *m,
+		keys:  opts.KeyCodec,
+		vals:  opts.ValueCodec,
+		cache: newCache[K](cacheSize),
+		dirty: make(map[pgid]*node[K]),
+	}
+	if tree.keys == nil {
+		tree.keys = defaultCodec[K]()
+	}
+	if tree.vals == nil {
+		tree.vals = defaultCodec[V]()
+	}
+
+	if tree.meta.root == 0 {
+		// A new file gets the meta page and an empty root leaf.
+		tree.meta.npages = 2
+		tree.meta.root = 1
+		tree.touch(&node[K]{id: 1, kind: kindLeaf})
+		tree.commit()
+		if tree.err != nil {
+			p.close()
+			return nil, tree.err
+		}
+	}
+	return tree, nil
+}
+
+// Insert adds key with data to the tree, leaving an existing key unchanged.
+// It panics if the encoded entry is larger than a quarter of a page.
+func (tree *Tree[K, V]) Insert(key K, data V) {
+	if tree == nil {
+		panic("btree: called Insert() on a nil tree")
+	}
+	tree.set(key, data, false)
+}
+
+// Put adds key with data to the tree, replacing the data of an existing key.
+// It panics if the encoded entry is larger than a quarter of a page.
+func (tree *Tree[K, V]) Put(key K, data V) {
+	if tree == nil {
+		panic("btree: called Put() on a nil tree")
+	}
+	tree.set(key, data, true)
+}
+
+func (tree *Tree[K, V]) Find(key K) *Node[K, V] {
+	if tree == nil {
+		panic("btree: called Find() on a nil tree")
+	}
+	if tree.err != nil {
+		return nil
+	}
+
+	n, err := tree.leaf(key)
+	if err != nil {
+		tree.fail(err)
+		return nil
+	}
+	i, found := slices.BinarySearch(n.keys, key)
+	if !found {
+		return nil
+	}
+	data, err := tree.vals.Decode(n.vals[i])
+	if err != nil {
+		tree.fail(fmt.Errorf("btree: decoding value on page %d: %w", n.id, err))
+		return nil
+	}
+	return &Node[K, V]{key: n.keys[i], data: data}
+}
+
+// Get returns the value of key and whether it is present.
+func (tree *Tree[K, V]) Get(key K) (val V, ok bool) {
+	if node := tree.Find(key); node != nil {
+		return node.data, true
+	}
+	return val, false
+}
+
+func (tree *Tree[K, V]) Remove(key K) {
+	if tree == nil {
+		panic("btree: called Remove() on a nil tree")
+	}
+	if tree.err != nil {
+		return
+	}
+
+	root, err := tree.node(tree.meta.root)
+	if err != nil {
+		tree.fail(err)
+		return
+	}
+	removed, err := tree.remove(root, key)
+	if err != nil {
+		tree.fail(err)
+		return
+	}
+	if !removed {
+		return
+	}
+	if root.kind == kindBranch && len(root.keys) == 0 {
+		tree.meta.root = root.children[0]
+		tree.release(root)
+	}
+	tree.commit()
+}
+
+func (tree *Tree[K, V]) Height() int {
+	if tree == nil || tree.err != nil {
+		return 0
+	}
+
+	h := 1
+	for id := tree.meta.root; ; h++ {
+		n, err := tree.node(id)
+		if err != nil {
+			tree.fail(err)
+			return 0
+		}
+		if n.kind == kindLeaf {
+			return h
+		}
+		id = n.children[0]
+	}
+}
+
+func (tree *Tree[K, V]) Size() int {
+	if tree == nil {
+		return 0
+	}
+	return tree.meta.count
+}
+
+func (tree *Tree[K, V]) Keys() []K {
+	keys := make([]K, 0, tree.Size())
+	for key := range tree.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// All returns an iterator over the key-value pairs of the tree in ascending key order.
+func (tree *Tree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil || tree.err != nil {
+			return
+		}
+		n, err := tree.first()
+		if err != nil {
+			tree.fail(err)
+			return
+		}
+		tree.scan(n, 0, func(K) bool { return true }, yield)
+	}
+}
+
+// Range returns an iterator over the key-value pairs with lo <= key <= hi in ascending key order.
+func (tree *Tree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree == nil || tree.err != nil || lo > hi {
+			return
+		}
+		n, err := tree.leaf(lo)
+		if err != nil {
+			tree.fail(err)
+			return
+		}
+		i, _ := slices.BinarySearch(n.keys, lo)
+		tree.scan(n, i, func(key K) bool { return key <= hi }, yield)
+	}
+}
+
+// Err returns the first error encountered by the tree.
+func (tree *Tree[K, V]) Err() error {
+	return tree.err
+}
+
+// Close closes the file. It returns the first error encountered by the tree,
+// if any, or the error from closing the file.
+func (tree *Tree[K, V]) Close() error {
+	if tree.pager == nil {
+		return ErrClosed
+	}
+	err := tree.pager.close()
+	if tree.err != nil {
+		err = tree.err
+	}
+	tree.pager = nil
+	tree.err = ErrClosed
+	return err
+}
+
+// Node is a key-value pair read from the tree.
+type Node[K any, V any] struct {
+	key  K
+	data V
+}
+
+func (node *Node[K, V]) Key() K {
+	return node.key
+}
+
+func (node *Node[K, V]) Data() V {
+	return node.data
+}
+
+func (node *Node[K, V]) String() string {
+	return fmt.Sprintf("%v", node.key)
+}
+
+func (tree *Tree[K, V]) set(key K, data V, replace bool) {
+	if tree.err != nil {
+		return
+	}
+
+	rawKey, err := tree.keys.Encode(key)
+	if err != nil {
+		tree.fail(fmt.Errorf("btree: encoding key: %w", err))
+		return
+	}
+	rawVal, err := tree.vals.Encode(data)
+	if err != nil {
+		tree.fail(fmt.Errorf("btree: encoding value: %w", err))
+		return
+	}
+	// Entries this small guarantee that an overfull node splits into two
+	// halves that fit in a page.
+	limit := (tree.meta.pageSize - pageOverhead - 8) / 4
+	if sz := entrySize(rawKey, rawVal); sz > limit {
+		panic(fmt.Sprintf("btree: entry of %d bytes exceeds the limit of %d bytes", sz, limit))
+	}
+
+	root, err := tree.node(tree.meta.root)
+	if err != nil {
+		tree.fail(err)
+		return
+	}
+	promo, err := tree.insert(root, key, rawKey, rawVal, replace)
+	if err != nil {
+		tree.fail(err)
+		return
+	}
+	if promo != nil {
+		id, err := tree.alloc()
+		if err != nil {
+			tree.fail(err)
+			return
+		}
+		tree.touch(&node[K]{
+			id:       id,
+			kind:     kindBranch,
+			keys:     []K{promo.key},
+			rawKeys:  [][]byte{promo.raw},
+			children: []pgid{root.id, promo.right},
+		})
+		tree.meta.root = id
+	}
+	tree.commit()
+}
+
+// promotion carries the first key of a new right sibling up to the parent.
+type promotion[K any] struct {
+	key   K
+	raw   []byte
+	right pgid
+}
+
+func (tree *Tree[K, V]) insert(n *node[K], key K, rawKey, rawVal []byte, replace bool) (*promotion[K], error) {
+	if n.kind == kindLeaf {
+		i, found := slices.BinarySearch(n.keys, key)
+		if found {
+			if !replace {
+				return nil, nil
+			}
+			n.vals[i] = rawVal
+			tree.touch(n)
+			return tree.split(n)
+		}
+		n.keys = slices.Insert(n.keys, i, key)
+		n.rawKeys = slices.Insert(n.rawKeys, i, rawKey)
+		n.vals = slices.Insert(n.vals, i, rawVal)
+		tree.meta.count++
+		tree.touch(n)
+		return tree.split(n)
+	}
+
+	i := childIndex(n.keys, key)
+	child, err := tree.node(n.children[i])
+	if err != nil {
+		return nil, err
+	}
+	promo, err := tree.insert(child, key, rawKey, rawVal, replace)
+	if err != nil || promo == nil {
+		return nil, err
+	}
+	n.keys = slices.Insert(n.keys, i, promo.key)
+	n.rawKeys = slices.Insert(n.rawKeys, i, promo.raw)
+	n.children = slices.Insert(n.children, i+1, promo.right)
+	tree.touch(n)
+	return tree.split(n)
+}
+
+// split moves the upper half of n into a new node if n no longer fits in a page.
+func (tree *Tree[K, V]) split(n *node[K]) (*promotion[K], error) {
+	if n.size() <= tree.meta.pageSize {
+		return nil, nil
+	}
+	id, err := tree.alloc()
+	if err != nil {
+		return nil, err
+	}
+	right, key, raw := n.cut(n.splitPoint())
+	right.id = id
+	if n.kind == kindLeaf {
+		n.next = id
+	}
+	tree.touch(right)
+	return &promotion[K]{key: key, raw: raw, right: id}, nil
+}
+
+func (tree *Tree[K, V]) remove(n *node[K], key K) (bool, error) {
+	if n.kind == kindLeaf {
+		i, found := slices.BinarySearch(n.keys, key)
+		if !found {
+			return false, nil
+		}
+		n.keys = slices.Delete(n.keys, i, i+1)
+		n.rawKeys = slices.Delete(n.rawKeys, i, i+1)
+		n.vals = slices.Delete(n.vals, i, i+1)
+		tree.meta.count--
+		tree.touch(n)
+		return true, nil
+	}
+
+	i := childIndex(n.keys, key)
+	child, err := tree.node(n.children[i])
+	if err != nil {
+		return false, err
+	}
+	removed, err := tree.remove(child, key)
+	if err != nil || !removed {
+		return removed, err
+	}
+	if child.size() < tree.meta.pageSize/4 {
+		err = tree.rebalance(n, i, child)
+	}
+	return true, err
+}
+
+// rebalance merges the underfull child at index i of parent with a sibling,
+// or evens out their entries when the two do not fit in one page.
+func (tree *Tree[K, V]) rebalance(parent *node[K], i int, child *node[K]) error {
+	li := min(i, len(parent.children)-2)
+	if li < 0 {
+		return nil
+	}
+
+	var left, right *node[K]
+	var err error
+	if li == i {
+		left = child
+		right, err = tree.node(parent.children[i+1])
+	} else {
+		left, err = tree.node(parent.children[li])
+		right = child
+	}
+	if err != nil {
+		return err
+	}
+
+	merged := concat(left, right, parent.keys[li], parent.rawKeys[li])
+	if merged.size() <= tree.meta.pageSize {
+		*left = *merged
+		tree.touch(left)
+		tree.release(right)
+		parent.keys = slices.Delete(parent.keys, li, li+1)
+		parent.rawKeys = slices.Delete(parent.rawKeys, li, li+1)
+		parent.children = slices.Delete(parent.children, li+1, li+2)
+	} else {
+		rest, key, raw := merged.cut(merged.splitPoint())
+		rest.id = right.id
+		if merged.kind == kindLeaf {
+			merged.next = rest.id
+		}
+		*left, *right = *merged, *rest
+		tree.touch(left)
+		tree.touch(right)
+		parent.keys[li] = key
+		parent.rawKeys[li] = raw
+	}
+	tree.touch(parent)
+	return nil
+}
+
+// childIndex returns the index of the child of a branch that holds key.
+func childIndex[K cmp.Ordered](keys []K, key K) int {
+	i, found := slices.BinarySearch(keys, key)
+	if found {
+		i++
+	}
+	return i
+}
+
+// leaf returns the leaf that holds key, or would hold it.
+func (tree *Tree[K, V]) leaf(key K) (*node[K], error) {
+	n, err := tree.node(tree.meta.root)
+	for err == nil && n.kind == kindBranch {
+		n, err = tree.node(n.children[childIndex(n.keys, key)])
+	}
+	return n, err
+}
+
+func (tree *Tree[K, V]) first() (*node[K], error) {
+	n, err := tree.node(tree.meta.root)
+	for err == nil && n.kind == kindBranch {
+		n, err = tree.node(n.children[0])
+	}
+	return n, err
+}
+
+// scan yields the entries of the leaves from index i of n onwards, following
+// the sibling links, while within reports true.
+func (tree *Tree[K, V]) scan(n *node[K], i int, within func(K) bool, yield func(K, V) bool) {
+	for {
+		for ; i < len(n.keys); i++ {
+			if !within(n.keys[i]) {
+				return
+			}
+			data, err := tree.vals.Decode(n.vals[i])
+			if err != nil {
+				tree.fail(fmt.Errorf("btree: decoding value on page %d: %w", n.id, err))
+				return
+			}
+			if !yield(n.keys[i], data) {
+				return
+			}
+		}
+		if n.next == 0 {
+			return
+		}
+
+		var err error
+		if n, err = tree.node(n.next); err != nil {
+			tree.fail(err)
+			return
+		}
+		i = 0
+	}
+}
+
+// node returns the page id, reading and decoding it if it is not cached.
+func (tree *Tree[K, V]) node(id pgid) (*node[K], error) {
+	if n, ok := tree.dirty[id]; ok {
+		return n, nil
+	}
+	if n := tree.cache.get(id); n != nil {
+		return n, nil
+	}
+
+	buf, err := tree.pager.read(id)
+	if err != nil {
+		return nil, err
+	}
+	n, err := decodeNode(id, buf, tree.keys)
+	if err != nil {
+		return nil, err
+	}
+	tree.cache.put(n)
+	return n, nil
+}
+
+// touch marks n as modified, it is written out by the next commit.
+func (tree *Tree[K, V]) touch(n *node[K]) {
+	tree.dirty[n.id] = n
+}
+
+// alloc returns the id of an unused page, taking it from the free list if possible.
+func (tree *Tree[K, V]) alloc() (pgid, error) {
+	if tree.meta.freelist == 0 {
+		tree.meta.npages++
+		return tree.meta.npages - 1, nil
+	}
+
+	n, err := tree.node(tree.meta.freelist)
+	if err != nil {
+		return 0, err
+	}
+	if n.kind != kindFree {
+		return 0, fmt.Errorf("%w: page %d on the free list is in use", ErrCorrupt, n.id)
+	}
+	tree.meta.freelist = n.next
+	delete(tree.dirty, n.id)
+	tree.cache.remove(n.id)
+	return n.id, nil
+}
+
+// release puts the page of n on the free list.
+func (tree *Tree[K, V]) release(n *node[K]) {
+	*n = node[K]{id: n.id, kind: kindFree, next: tree.meta.freelist}
+	tree.meta.freelist = n.id
+	tree.touch(n)
+}
+
+// commit writes the modified pages and the meta page to the file.
+func (tree *Tree[K, V]) commit() {
+	pages := make(map[pgid][]byte, len(tree.dirty)+1)
+	for id, n := range tree.dirty {
+		buf := make([]byte, tree.meta.pageSize)
+		n.encode(buf)
+		pages[id] = buf
+	}
+	buf := make([]byte, tree.meta.pageSize)
+	tree.meta.encode(buf)
+	pages[0] = buf
+
+	if err := tree.pager.write(pages); err != nil {
+		tree.fail(err)
+		return
+	}
+	for id, n := range tree.dirty {
+		if n.kind == kindFree {
+			tree.cache.remove(id)
+		} else {
+			tree.cache.put(n)
+		}
+	}
+	clear(tree.dirty)
+}
+
+func (tree *Tree[K, V]) fail(err error) {
+	if tree.err == nil {
+		tree.err = err
+	}
+}
@@ -0,0 +1,428 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func openTestTree(t *testing.T, path string) *Tree[int, string] {
+	t.Helper()
+	tree, err := Open(path, &Options[int, string]{PageSize: minPageSize, CacheSize: 8, NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+// checkTree verifies that the keys are ordered, that every leaf is at the same
+// depth and that the sibling links visit every leaf in order.
+func checkTree(t *testing.T, tree *Tree[int, string]) {
+	t.Helper()
+
+	var leaves []pgid
+	var walk func(id pgid, depth int, lo, hi *int) int
+	walk = func(id pgid, depth int, lo, hi *int) int {
+		n, err := tree.node(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.size() > tree.meta.pageSize {
+			t.Fatalf("Expected page %d to fit in a page, got %d bytes instead", id, n.size())
+		}
+		for i, key := range n.keys {
+			if (lo != nil && key < *lo) || (hi != nil && key >= *hi) || (i > 0 && key <= n.keys[i-1]) {
+				t.Fatalf("Expected keys of page %d to be ordered and in range, got %v instead", id, n.keys)
+			}
+		}
+		if n.kind == kindLeaf {
+			leaves = append(leaves, id)
+			return depth
+		}
+
+		leafDepth := -1
+		for i, child := range n.children {
+			clo, chi := lo, hi
+			if i > 0 {
+				clo = &n.keys[i-1]
+			}
+			if i < len(n.keys) {
+				chi = &n.keys[i]
+			}
+			d := walk(child, depth+1, clo, chi)
+			if leafDepth != -1 && d != leafDepth {
+				t.Fatalf("Expected every leaf at depth %d, got %d instead", leafDepth, d)
+			}
+			leafDepth = d
+		}
+		return leafDepth
+	}
+	walk(tree.meta.root, 1, nil, nil)
+
+	for i, id := range leaves {
+		n, _ := tree.node(id)
+		want := pgid(0)
+		if i+1 < len(leaves) {
+			want = leaves[i+1]
+		}
+		if n.next != want {
+			t.Fatalf("Expected leaf %d to link to %d, got %d instead", id, want, n.next)
+		}
+	}
+	if n := len(tree.Keys()); n != tree.Size() {
+		t.Fatalf("Expected %d keys, got %d instead", tree.Size(), n)
+	}
+}
+
+func TestTreeInsertFind(t *testing.T) {
+	tree := openTestTree(t, filepath.Join(t.TempDir(), "tree.db"))
+	defer tree.Close()
+
+	keys := rand.Perm(20000)
+	for _, k := range keys {
+		tree.Insert(k, string(rune('a'+k%26)))
+	}
+	tree.Insert(keys[0], "replaced")
+
+	if err := tree.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n := tree.Size(); n != len(keys) {
+		t.Errorf("Expected size to be %d, got %d instead", len(keys), n)
+	}
+	if h := tree.Height(); h < 3 {
+		t.Errorf("Expected height of at least 3, got %d instead", h)
+	}
+	for _, k := range keys {
+		node := tree.Find(k)
+		if node == nil || node.Key() != k || node.Data() != string(rune('a'+k%26)) {
+			t.Fatalf("Expected to find key %d, got %v instead", k, node)
+		}
+	}
+	if node := tree.Find(-1); node != nil {
+		t.Errorf("Expected nil for a missing key, got %v instead", node)
+	}
+	if v, ok := tree.Get(keys[0]); !ok || v != string(rune('a'+keys[0]%26)) {
+		t.Errorf("Expected Get(%d) to find the first value, got (%q, %t) instead", keys[0], v, ok)
+	}
+	if _, ok := tree.Get(-1); ok {
+		t.Error("Expected Get(-1) to report a missing key")
+	}
+	checkTree(t, tree)
+}
+
+func TestTreePut(t *testing.T) {
+	tree := openTestTree(t, filepath.Join(t.TempDir(), "tree.db"))
+	defer tree.Close()
+
+	for i := range 100 {
+		tree.Put(i, "x")
+	}
+	for i := range 100 {
+		tree.Put(i, string(make([]byte, 100)))
+	}
+
+	if n := tree.Size(); n != 100 {
+		t.Errorf("Expected size to be 100, got %d instead", n)
+	}
+	if node := tree.Find(42); node == nil || len(node.Data()) != 100 {
+		t.Errorf("Expected the value of 42 to be replaced, got %v instead", node)
+	}
+	checkTree(t, tree)
+}
+
+func TestTreeRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTestTree(t, path)
+	defer tree.Close()
+
+	for i := range 2000 {
+		tree.Insert(i, "value")
+	}
+	for _, k := range rand.Perm(2000) {
+		if k%2 == 0 {
+			tree.Remove(k)
+		}
+	}
+	tree.Remove(-1)
+	checkTree(t, tree)
+
+	if n := tree.Size(); n != 1000 {
+		t.Errorf("Expected size to be 1000, got %d instead", n)
+	}
+	for i := range 2000 {
+		if found := tree.Find(i) != nil; found != (i%2 == 1) {
+			t.Fatalf("Expected Find(%d) to report %v, got %v instead", i, i%2 == 1, found)
+		}
+	}
+
+	for _, k := range rand.Perm(2000) {
+		tree.Remove(k)
+	}
+	checkTree(t, tree)
+	if n, h := tree.Size(), tree.Height(); n != 0 || h != 1 {
+		t.Errorf("Expected an empty tree of height 1, got size %d and height %d instead", n, h)
+	}
+
+	// The pages freed by the removals are reused.
+	info, _ := os.Stat(path)
+	for i := range 2000 {
+		tree.Insert(i, "value")
+	}
+	if after, _ := os.Stat(path); after.Size() > info.Size() {
+		t.Errorf("Expected the file to stay at %d bytes, got %d instead", info.Size(), after.Size())
+	}
+	if err := tree.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTreeRange(t *testing.T) {
+	tree := openTestTree(t, filepath.Join(t.TempDir(), "tree.db"))
+	defer tree.Close()
+
+	for i := 0; i < 1000; i += 2 {
+		tree.Insert(i, "v")
+	}
+
+	tests := []struct {
+		lo, hi int
+		want   []int
+	}{
+		{lo: 10, hi: 20, want: []int{10, 12, 14, 16, 18, 20}},
+		{lo: 11, hi: 13, want: []int{12}},
+		{lo: -10, hi: 2, want: []int{0, 2}},
+		{lo: 995, hi: 2000, want: []int{996, 998}},
+		{lo: 13, hi: 13, want: nil},
+		{lo: 20, hi: 10, want: nil},
+	}
+
+	for _, tt := range tests {
+		var got []int
+		for k := range tree.Range(tt.lo, tt.hi) {
+			got = append(got, k)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Expected Range(%d, %d) to yield %v, got %v instead", tt.lo, tt.hi, tt.want, got)
+		}
+	}
+
+	count := 0
+	for range tree.Range(0, 998) {
+		count++
+		if count == 300 {
+			break
+		}
+	}
+	if count != 300 {
+		t.Errorf("Expected the scan to stop at 300, got %d instead", count)
+	}
+}
+
+func TestTreeReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTestTree(t, path)
+	for i := range 500 {
+		tree.Insert(i, "value")
+	}
+	for i := range 100 {
+		tree.Remove(i)
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v instead", err)
+	}
+
+	// The page size is read from the file.
+	reopened, err := Open[int, string](path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	if n := reopened.Size(); n != 400 {
+		t.Errorf("Expected size to be 400, got %d instead", n)
+	}
+	if keys := reopened.Keys(); keys[0] != 100 || keys[len(keys)-1] != 499 {
+		t.Errorf("Expected keys from 100 to 499, got %v instead", keys)
+	}
+	checkTree(t, reopened)
+}
+
+func TestTreeRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+
+	crash := func(torn bool) {
+		tree := openTestTree(t, path)
+		for i := range 200 {
+			tree.Insert(i, "value")
+		}
+		// Writes to the file fail after the journal has been synced.
+		tree.pager.file.Close()
+		tree.Insert(1000, "last")
+		if tree.Err() == nil {
+			t.Fatal("Expected the write to fail")
+		}
+		tree.pager.journal.Close()
+
+		if torn {
+			info, _ := os.Stat(path + "-journal")
+			os.Truncate(path+"-journal", info.Size()-1)
+		}
+	}
+
+	// Reopen with the default page size: the journal must still be replayed
+	// with the page size of the file.
+	crash(false)
+	tree, err := Open[int, string](path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := tree.Size(); n != 201 || tree.Find(1000) == nil {
+		t.Errorf("Expected the journal to be replayed, got size %d instead", n)
+	}
+	checkTree(t, tree)
+	tree.Close()
+
+	os.Remove(path)
+	crash(true)
+	tree = openTestTree(t, path)
+	if n := tree.Size(); n != 200 || tree.Find(1000) != nil {
+		t.Errorf("Expected the torn journal to be dropped, got size %d instead", n)
+	}
+	checkTree(t, tree)
+	tree.Close()
+
+	// A journal written with another page size must not be replayed.
+	pageSize := 2 * minPageSize
+	rec := make([]byte, journalHeaderSize, journalHeaderSize+8+pageSize+4)
+	copy(rec, journalMagic)
+	binary.LittleEndian.PutUint32(rec[8:], uint32(pageSize))
+	binary.LittleEndian.PutUint32(rec[12:], 1)
+	rec = binary.LittleEndian.AppendUint64(rec, 1)
+	rec = append(rec, make([]byte, pageSize)...)
+	rec = binary.LittleEndian.AppendUint32(rec, crc32.ChecksumIEEE(rec))
+	if err := os.WriteFile(path+"-journal", rec, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open[int, string](path, nil); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a mismatched journal, got %v instead", err)
+	}
+}
+
+func TestTreeCorruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTestTree(t, path)
+	for i := range 200 {
+		tree.Insert(i, "value")
+	}
+	tree.Close()
+
+	f, _ := os.OpenFile(path, os.O_RDWR, 0)
+	f.WriteAt([]byte{0xff}, 2*minPageSize+100)
+	f.Close()
+
+	tree = openTestTree(t, path)
+	defer tree.Close()
+	for i := range 200 {
+		tree.Find(i)
+	}
+	if err := tree.Err(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v instead", err)
+	}
+	tree.Insert(500, "value")
+	if n := tree.Size(); n != 200 {
+		t.Errorf("Expected no writes after an error, got size %d instead", n)
+	}
+}
+
+func TestTreeCodecs(t *testing.T) {
+	type point struct{ X, Y int }
+
+	tree, err := Open[string, point](filepath.Join(t.TempDir(), "tree.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	tree.Insert("b", point{1, 2})
+	tree.Insert("a", point{3, 4})
+
+	if node := tree.Find("b"); node == nil || node.Data() != (point{1, 2}) {
+		t.Errorf("Expected {1 2}, got %v instead", node)
+	}
+	if keys := tree.Keys(); !slices.Equal(keys, []string{"a", "b"}) {
+		t.Errorf("Expected [a b], got %v instead", keys)
+	}
+
+	for _, v := range []float64{-1.5, 0, 3.25} {
+		codec := defaultCodec[float64]()
+		data, _ := codec.Encode(v)
+		if got, err := codec.Decode(data); err != nil || got != v {
+			t.Errorf("Expected %v, got %v instead", v, got)
+		}
+	}
+	for _, v := range [][]byte{{}, {1, 2, 3}} {
+		codec := defaultCodec[[]byte]()
+		data, _ := codec.Encode(v)
+		if got, _ := codec.Decode(data); !slices.Equal(got, v) {
+			t.Errorf("Expected %v, got %v instead", v, got)
+		}
+	}
+}
+
+func TestTreeBytesNotAliased(t *testing.T) {
+	tree, err := Open[int, []byte](filepath.Join(t.TempDir(), "tree.db"), &Options[int, []byte]{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	buf := []byte("hello")
+	tree.Put(1, buf)
+	copy(buf, "world")
+
+	if node := tree.Find(1); node == nil || string(node.Data()) != "hello" {
+		t.Errorf("Expected \"hello\", got %v instead", node)
+	}
+}
+
+func TestTreeEntryTooLarge(t *testing.T) {
+	tree := openTestTree(t, filepath.Join(t.TempDir(), "tree.db"))
+	defer tree.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Insert() to panic on an oversized entry")
+		}
+	}()
+	tree.Insert(1, string(make([]byte, minPageSize)))
+}
@@ -0,0 +1,71 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import "container/list"
+
+const defaultCacheSize = 256
+
+// cache keeps the most recently used decoded pages.
+type cache[K any] struct {
+	capacity int
+	order    *list.List
+	items    map[pgid]*list.Element
+}
+
+func newCache[K any](capacity int) *cache[K] {
+	return &cache[K]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[pgid]*list.Element, capacity),
+	}
+}
+
+func (c *cache[K]) get(id pgid) *node[K] {
+	elem, ok := c.items[id]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*node[K])
+}
+
+func (c *cache[K]) put(n *node[K]) {
+	if elem, ok := c.items[n.id]; ok {
+		elem.Value = n
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[n.id] = c.order.PushFront(n)
+	for c.order.Len() > c.capacity {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.items, last.Value.(*node[K]).id)
+	}
+}
+
+func (c *cache[K]) remove(id pgid) {
+	if elem, ok := c.items[id]; ok {
+		c.order.Remove(elem)
+		delete(c.items, id)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"reflect"
+)
+
+// Codec converts keys or values to and from the bytes stored in the pages.
+// The encoding does not need to preserve order, keys are always compared
+// in their decoded form.
+type Codec[T any] interface {
+	Encode(v T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+var errShortData = errors.New("btree: short data")
+
+// defaultCodec returns a compact codec for strings, byte slices, integers,
+// floats and booleans, and a gob codec for every other type.
+func defaultCodec[T any]() Codec[T] {
+	var zero T
+	if _, ok := any(zero).([]byte); ok {
+		return bytesCodec[T]{}
+	}
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.String:
+		return stringCodec[T]{}
+	case reflect.Int:
+		return intCodec[T]{}
+	case reflect.Uint, reflect.Uintptr:
+		return uintCodec[T]{}
+	case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fixedCodec[T]{}
+	default:
+		return gobCodec[T]{}
+	}
+}
+
+type bytesCodec[T any] struct{}
+
+func (bytesCodec[T]) Encode(v T) ([]byte, error) {
+	return bytes.Clone(any(v).([]byte)), nil
+}
+
+func (bytesCodec[T]) Decode(data []byte) (T, error) {
+	return any(bytes.Clone(data)).(T), nil
+}
+
+type stringCodec[T any] struct{}
+
+func (stringCodec[T]) Encode(v T) ([]byte, error) {
+	return []byte(reflect.ValueOf(v).String()), nil
+}
+
+func (stringCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	reflect.ValueOf(&v).Elem().SetString(string(data))
+	return v, nil
+}
+
+type intCodec[T any] struct{}
+
+func (intCodec[T]) Encode(v T) ([]byte, error) {
+	return binary.AppendVarint(nil, reflect.ValueOf(v).Int()), nil
+}
+
+func (intCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	x, n := binary.Varint(data)
+	if n <= 0 {
+		return v, errShortData
+	}
+	reflect.ValueOf(&v).Elem().SetInt(x)
+	return v, nil
+}
+
+type uintCodec[T any] struct{}
+
+func (uintCodec[T]) Encode(v T) ([]byte, error) {
+	return binary.AppendUvarint(nil, reflect.ValueOf(v).Uint()), nil
+}
+
+func (uintCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	x, n := binary.Uvarint(data)
+	if n <= 0 {
+		return v, errShortData
+	}
+	reflect.ValueOf(&v).Elem().SetUint(x)
+	return v, nil
+}
+
+type fixedCodec[T any] struct{}
+
+func (fixedCodec[T]) Encode(v T) ([]byte, error) {
+	return binary.Append(nil, binary.LittleEndian, v)
+}
+
+func (fixedCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	_, err := binary.Decode(data, binary.LittleEndian, &v)
+	return v, err
+}
+
+type gobCodec[T any] struct{}
+
+func (gobCodec[T]) Encode(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
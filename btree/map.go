@@ -0,0 +1,359 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+)
+
+// DefaultDegree is a degree that suits small keys and values.
+const DefaultDegree = 16
+
+// Map is an in-memory B-tree. Every node stores its keys, values and children
+// in arrays sized for the maximum fill, so a lookup touches a few contiguous
+// arrays per level instead of one heap node per key.
+//
+// A map of degree t holds between t-1 and 2t-1 keys in every node other than
+// the root, and an internal node with n keys has n+1 children. The zero Map is
+// empty, ready to use and has degree DefaultDegree.
+//
+// Map has the methods of bst.Tree except EnableStats, Stats and SetObserver,
+// which report AVL rotations.
+type Map[K cmp.Ordered, V any] struct {
+	root   *mapNode[K, V]
+	size   int
+	degree int
+	mods   int
+}
+
+// NewMap returns an empty map of the given degree ordered by the natural
+// ordering of K. It panics if degree is less than 2.
+func NewMap[K cmp.Ordered, V any](degree int) *Map[K, V] {
+	if degree < 2 {
+		panic(fmt.Sprintf("btree: degree must be at least 2, got %d", degree))
+	}
+	return &Map[K, V]{degree: degree}
+}
+
+func (m *Map[K, V]) Insert(key K, data V) {
+	if m == nil {
+		panic("btree: called Insert() on a nil map")
+	}
+	m.insert(key, data, false)
+}
+
+// Put sets the value for key, inserting it if missing. It returns the previous
+// value and whether one was replaced.
+func (m *Map[K, V]) Put(key K, val V) (old V, replaced bool) {
+	if m == nil {
+		panic("btree: called Put() on a nil map")
+	}
+	return m.insert(key, val, true)
+}
+
+// GetOrInsert returns the existing value for key if present. Otherwise it
+// inserts val and returns it. The loaded result is true if the value was present.
+func (m *Map[K, V]) GetOrInsert(key K, val V) (actual V, loaded bool) {
+	if m == nil {
+		panic("btree: called GetOrInsert() on a nil map")
+	}
+	if n, i := m.find(key); n != nil {
+		return n.vals[i], true
+	}
+	m.insert(key, val, false)
+	return val, false
+}
+
+// Update calls fn with the current value for key and whether it exists. The key
+// is set to the returned value if keep is true, and removed otherwise.
+func (m *Map[K, V]) Update(key K, fn func(old V, exists bool) (val V, keep bool)) {
+	if m == nil {
+		panic("btree: called Update() on a nil map")
+	}
+
+	var old V
+	n, i := m.find(key)
+	if n != nil {
+		old = n.vals[i]
+	}
+
+	val, keep := fn(old, n != nil)
+	switch {
+	case !keep && n != nil:
+		m.delete(key)
+	case keep && n != nil:
+		n.vals[i] = val
+	case keep:
+		m.insert(key, val, false)
+	}
+}
+
+// Find returns a copy of the entry for key, or nil. Entries live in the arrays
+// of their nodes, so the copy is allocated on every hit; Get does not allocate.
+func (m *Map[K, V]) Find(key K) *Node[K, V] {
+	if m == nil {
+		panic("btree: called Find() on a nil map")
+	}
+	return entry(m.find(key))
+}
+
+// Get returns the value of key and whether it is present.
+func (m *Map[K, V]) Get(key K) (val V, ok bool) {
+	if m == nil {
+		panic("btree: called Get() on a nil map")
+	}
+	n, i := m.find(key)
+	if n == nil {
+		return val, false
+	}
+	return n.vals[i], true
+}
+
+func (m *Map[K, V]) Remove(key K) {
+	if m == nil {
+		panic("btree: called Remove() on a nil map")
+	}
+	m.delete(key)
+}
+
+// Delete removes key from the map and returns its value, if it was present.
+func (m *Map[K, V]) Delete(key K) (val V, ok bool) {
+	if m == nil {
+		panic("btree: called Delete() on a nil map")
+	}
+	return m.delete(key)
+}
+
+func (m *Map[K, V]) Height() int {
+	if m == nil || m.root == nil {
+		return 0
+	}
+	h := 1
+	for n := m.root; !n.leaf(); n = n.children[0] {
+		h++
+	}
+	return h
+}
+
+func (m *Map[K, V]) Size() int {
+	if m == nil {
+		return 0
+	}
+	return m.size
+}
+
+func (m *Map[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Size())
+	for key := range m.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// All returns an iterator over the key-value pairs of the map in ascending key order.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m != nil && m.root != nil {
+			m.root.walk(yield)
+		}
+	}
+}
+
+// Backward returns an iterator over the key-value pairs of the map in descending key order.
+func (m *Map[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m != nil && m.root != nil {
+			m.root.walkBackward(yield)
+		}
+	}
+}
+
+// Range returns an iterator over the key-value pairs with lo <= key <= hi in ascending key order.
+func (m *Map[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m != nil && m.root != nil && cmp.Compare(lo, hi) <= 0 {
+			m.root.walkRange(lo, hi, yield)
+		}
+	}
+}
+
+// Floor returns the entry with the greatest key less than or equal to key, or nil.
+func (m *Map[K, V]) Floor(key K) *Node[K, V] {
+	if m == nil {
+		panic("btree: called Floor() on a nil map")
+	}
+	return entry(m.floor(key, true))
+}
+
+// Ceiling returns the entry with the smallest key greater than or equal to key, or nil.
+func (m *Map[K, V]) Ceiling(key K) *Node[K, V] {
+	if m == nil {
+		panic("btree: called Ceiling() on a nil map")
+	}
+	return entry(m.ceiling(key, true))
+}
+
+// Predecessor returns the entry with the greatest key strictly less than key, or nil.
+func (m *Map[K, V]) Predecessor(key K) *Node[K, V] {
+	if m == nil {
+		panic("btree: called Predecessor() on a nil map")
+	}
+	return entry(m.floor(key, false))
+}
+
+// Successor returns the entry with the smallest key strictly greater than key, or nil.
+func (m *Map[K, V]) Successor(key K) *Node[K, V] {
+	if m == nil {
+		panic("btree: called Successor() on a nil map")
+	}
+	return entry(m.ceiling(key, false))
+}
+
+// Min returns the entry with the smallest key, or nil if the map is empty.
+func (m *Map[K, V]) Min() *Node[K, V] {
+	if m == nil || m.root == nil {
+		return nil
+	}
+	n := m.root
+	for !n.leaf() {
+		n = n.children[0]
+	}
+	return entry(n, 0)
+}
+
+// Max returns the entry with the greatest key, or nil if the map is empty.
+func (m *Map[K, V]) Max() *Node[K, V] {
+	if m == nil || m.root == nil {
+		return nil
+	}
+	n := m.root
+	for !n.leaf() {
+		n = n.children[len(n.children)-1]
+	}
+	return entry(n, len(n.keys)-1)
+}
+
+// PopMin removes and returns the entry with the smallest key.
+func (m *Map[K, V]) PopMin() (key K, val V, ok bool) {
+	if m == nil {
+		panic("btree: called PopMin() on a nil map")
+	}
+	if m.root == nil {
+		return key, val, false
+	}
+	key, val = m.deleteMin(m.root)
+	m.size--
+	m.mods++
+	m.shrink()
+	return key, val, true
+}
+
+// PopMax removes and returns the entry with the greatest key.
+func (m *Map[K, V]) PopMax() (key K, val V, ok bool) {
+	if m == nil {
+		panic("btree: called PopMax() on a nil map")
+	}
+	if m.root == nil {
+		return key, val, false
+	}
+	key, val = m.deleteMax(m.root)
+	m.size--
+	m.mods++
+	m.shrink()
+	return key, val, true
+}
+
+// Clone returns a copy of the map. The keys and values are copied shallowly.
+func (m *Map[K, V]) Clone() *Map[K, V] {
+	if m == nil {
+		panic("btree: called Clone() on a nil map")
+	}
+	c := *m
+	c.root = m.cloneNode(m.root)
+	return &c
+}
+
+// Validate checks the structural invariants of the map: key ordering, the
+// number of keys in every node, the depth of the leaves and the cached
+// subtree sizes.
+func (m *Map[K, V]) Validate() error {
+	if m == nil || m.root == nil {
+		if m != nil && m.size != 0 {
+			return fmt.Errorf("btree: empty map has size %d", m.size)
+		}
+		return nil
+	}
+
+	leafDepth := -1
+	var walk func(n *mapNode[K, V], depth int, lo, hi *K) (int, error)
+	walk = func(n *mapNode[K, V], depth int, lo, hi *K) (int, error) {
+		if len(n.keys) == 0 || len(n.keys) > m.maxKeys() || (n != m.root && len(n.keys) < m.degree-1) {
+			return 0, fmt.Errorf("btree: node at depth %d has %d keys", depth, len(n.keys))
+		}
+		if len(n.vals) != len(n.keys) || (!n.leaf() && len(n.children) != len(n.keys)+1) {
+			return 0, fmt.Errorf("btree: node at depth %d has %d keys, %d values and %d children",
+				depth, len(n.keys), len(n.vals), len(n.children))
+		}
+		for i, key := range n.keys {
+			if (lo != nil && cmp.Compare(key, *lo) <= 0) || (hi != nil && cmp.Compare(key, *hi) >= 0) ||
+				(i > 0 && cmp.Compare(key, n.keys[i-1]) <= 0) {
+				return 0, fmt.Errorf("btree: node at depth %d: key %v is out of order", depth, key)
+			}
+		}
+		size := len(n.keys)
+
+		if n.leaf() {
+			if leafDepth != -1 && depth != leafDepth {
+				return 0, fmt.Errorf("btree: leaf at depth %d, expected %d", depth, leafDepth)
+			}
+			leafDepth = depth
+		}
+		for i, child := range n.children {
+			clo, chi := lo, hi
+			if i > 0 {
+				clo = &n.keys[i-1]
+			}
+			if i < len(n.keys) {
+				chi = &n.keys[i]
+			}
+			childSize, err := walk(child, depth+1, clo, chi)
+			if err != nil {
+				return 0, err
+			}
+			size += childSize
+		}
+		if n.size != size {
+			return 0, fmt.Errorf("btree: node at depth %d has size %d, expected %d", depth, n.size, size)
+		}
+		return size, nil
+	}
+	size, err := walk(m.root, 1, nil, nil)
+	if err != nil {
+		return err
+	}
+	if m.size != size {
+		return fmt.Errorf("btree: map size is %d, expected %d", m.size, size)
+	}
+	return nil
+}
@@ -0,0 +1,578 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nmezhenskyi/ds/bst"
+)
+
+var (
+	_ bst.OrderedMap[int, int] = (*Tree[int, int])(nil)
+	_ bst.OrderedMap[int, int] = (*Map[int, int])(nil)
+)
+
+func TestMapRandom(t *testing.T) {
+	for _, degree := range []int{2, 3, 16} {
+		m := NewMap[int, int](degree)
+		ref := make(map[int]int)
+
+		for i := range 20000 {
+			k := rand.IntN(2000)
+			switch rand.IntN(3) {
+			case 0:
+				m.Insert(k, i)
+				if _, ok := ref[k]; !ok {
+					ref[k] = i
+				}
+			case 1:
+				old, replaced := m.Put(k, i)
+				if prev, ok := ref[k]; ok != replaced || old != prev {
+					t.Fatalf("Expected Put(%d) to return (%d, %v), got (%d, %v) instead", k, prev, ok, old, replaced)
+				}
+				ref[k] = i
+			case 2:
+				val, ok := m.Delete(k)
+				if prev, exists := ref[k]; ok != exists || val != prev {
+					t.Fatalf("Expected Delete(%d) to return (%d, %v), got (%d, %v) instead", k, prev, exists, val, ok)
+				}
+				delete(ref, k)
+			}
+			if i%1000 == 0 {
+				if err := m.Validate(); err != nil {
+					t.Fatalf("degree %d: %v", degree, err)
+				}
+			}
+		}
+
+		if err := m.Validate(); err != nil {
+			t.Fatalf("degree %d: %v", degree, err)
+		}
+		if m.Size() != len(ref) {
+			t.Errorf("Expected size to be %d, got %d instead", len(ref), m.Size())
+		}
+		for k, v := range ref {
+			if node := m.Find(k); node == nil || node.Data() != v {
+				t.Fatalf("Expected to find %d with %d, got %v instead", k, v, node)
+			}
+			if got, ok := m.Get(k); !ok || got != v {
+				t.Fatalf("Expected Get(%d) to be (%d, true), got (%d, %t) instead", k, v, got, ok)
+			}
+		}
+		if _, ok := m.Get(-1); ok {
+			t.Error("Expected Get(-1) to report a missing key")
+		}
+		if !slices.IsSorted(m.Keys()) {
+			t.Errorf("Expected keys in ascending order")
+		}
+
+		for k := range ref {
+			m.Remove(k)
+		}
+		if m.Size() != 0 || m.Height() != 0 || m.Validate() != nil {
+			t.Errorf("Expected an empty map, got size %d and height %d instead", m.Size(), m.Height())
+		}
+	}
+}
+
+func TestMapZero(t *testing.T) {
+	var m Map[int, int]
+
+	if _, ok := m.Get(1); ok || m.Min() != nil || m.Size() != 0 {
+		t.Fatal("Expected the zero map to be empty")
+	}
+	for _, k := range rand.Perm(1000) {
+		m.Insert(k, k)
+	}
+	if m.degree != DefaultDegree {
+		t.Errorf("Expected degree %d, got %d instead", DefaultDegree, m.degree)
+	}
+	if m.Size() != 1000 {
+		t.Errorf("Expected size to be 1000, got %d instead", m.Size())
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapNavigation(t *testing.T) {
+	m := NewMap[int, string](2)
+	for i := 0; i <= 100; i += 10 {
+		m.Insert(i, fmt.Sprint(i))
+	}
+
+	tests := []struct {
+		name string
+		fn   func(int) *Node[int, string]
+		key  int
+		want int
+		ok   bool
+	}{
+		{name: "Floor", fn: m.Floor, key: 35, want: 30, ok: true},
+		{name: "Floor", fn: m.Floor, key: 40, want: 40, ok: true},
+		{name: "Floor", fn: m.Floor, key: -1, ok: false},
+		{name: "Ceiling", fn: m.Ceiling, key: 35, want: 40, ok: true},
+		{name: "Ceiling", fn: m.Ceiling, key: 101, ok: false},
+		{name: "Predecessor", fn: m.Predecessor, key: 40, want: 30, ok: true},
+		{name: "Predecessor", fn: m.Predecessor, key: 0, ok: false},
+		{name: "Successor", fn: m.Successor, key: 40, want: 50, ok: true},
+		{name: "Successor", fn: m.Successor, key: 100, ok: false},
+	}
+
+	for _, tt := range tests {
+		node := tt.fn(tt.key)
+		if (node != nil) != tt.ok || (node != nil && node.Key() != tt.want) {
+			t.Errorf("Expected %s(%d) to be %d (%v), got %v instead", tt.name, tt.key, tt.want, tt.ok, node)
+		}
+	}
+
+	if min, max := m.Min(), m.Max(); min.Key() != 0 || max.Key() != 100 {
+		t.Errorf("Expected min 0 and max 100, got %v and %v instead", min, max)
+	}
+
+	var got []int
+	for k := range m.Range(15, 55) {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []int{20, 30, 40, 50}) {
+		t.Errorf("Expected [20 30 40 50], got %v instead", got)
+	}
+
+	got = got[:0]
+	for k := range m.Backward() {
+		got = append(got, k)
+	}
+	if want := []int{100, 90, 80, 70, 60, 50, 40, 30, 20, 10, 0}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v instead", want, got)
+	}
+}
+
+func TestMapPop(t *testing.T) {
+	m := NewMap[int, int](3)
+	for _, k := range rand.Perm(500) {
+		m.Insert(k, k)
+	}
+
+	for i := range 250 {
+		if k, _, ok := m.PopMin(); !ok || k != i {
+			t.Fatalf("Expected PopMin() to return %d, got %d instead", i, k)
+		}
+		if k, _, ok := m.PopMax(); !ok || k != 499-i {
+			t.Fatalf("Expected PopMax() to return %d, got %d instead", 499-i, k)
+		}
+		if err := m.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, ok := m.PopMin(); ok {
+		t.Errorf("Expected PopMin() on an empty map to fail")
+	}
+}
+
+func TestMapCloneUpdate(t *testing.T) {
+	m := NewMap[int, int](2)
+	for i := range 50 {
+		m.Insert(i, i)
+	}
+
+	c := m.Clone()
+	c.Update(10, func(old int, exists bool) (int, bool) { return old * 100, true })
+	c.Update(20, func(int, bool) (int, bool) { return 0, false })
+	c.Update(99, func(old int, exists bool) (int, bool) { return 99, !exists })
+
+	if node := m.Find(10); node.Data() != 10 {
+		t.Errorf("Expected the original map to keep 10, got %d instead", node.Data())
+	}
+	if node := c.Find(10); node.Data() != 1000 {
+		t.Errorf("Expected the clone to have 1000, got %d instead", node.Data())
+	}
+	if c.Find(20) != nil || c.Find(99) == nil || c.Size() != 50 || m.Size() != 50 {
+		t.Errorf("Expected Update() to remove 20 and insert 99 in the clone only")
+	}
+	if v, loaded := c.GetOrInsert(99, 0); v != 99 || !loaded {
+		t.Errorf("Expected GetOrInsert() to load 99, got %d instead", v)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestMap(degree int, keys ...int) *Map[int, int] {
+	m := NewMap[int, int](degree)
+	for _, k := range keys {
+		m.Insert(k, k)
+	}
+	return m
+}
+
+func TestMapRank(t *testing.T) {
+	for _, degree := range []int{2, 3, 16} {
+		keys := rand.Perm(1000)
+		m := newTestMap(degree, keys...)
+		for _, k := range keys[:500] {
+			m.Remove(k)
+		}
+		ref := m.Keys()
+
+		for i, k := range ref {
+			if r := m.Rank(k); r != i {
+				t.Fatalf("degree %d: Expected Rank(%d) to be %d, got %d instead", degree, k, i, r)
+			}
+			if node := m.Select(i); node.Key() != k {
+				t.Fatalf("degree %d: Expected Select(%d) to be %d, got %v instead", degree, i, k, node)
+			}
+		}
+		for range 100 {
+			lo, hi := rand.IntN(1100)-50, rand.IntN(1100)-50
+			expected := 0
+			for _, k := range ref {
+				if lo <= k && k <= hi {
+					expected++
+				}
+			}
+			if n := m.CountRange(lo, hi); n != expected {
+				t.Fatalf("degree %d: Expected CountRange(%d, %d) to be %d, got %d instead", degree, lo, hi, expected, n)
+			}
+		}
+	}
+}
+
+func TestMapLoad(t *testing.T) {
+	for _, degree := range []int{2, 3, 5} {
+		for n := range 300 {
+			keys := make([]int, n)
+			for i := range keys {
+				keys[i] = i
+			}
+			m := NewMap[int, int](degree)
+			m.load(keys, keys)
+			if err := m.Validate(); err != nil {
+				t.Fatalf("degree %d, %d keys: %v", degree, n, err)
+			}
+			if !slices.Equal(m.Keys(), keys) {
+				t.Fatalf("degree %d: Expected keys %v, got %v instead", degree, keys, m.Keys())
+			}
+		}
+	}
+}
+
+func TestMapSplit(t *testing.T) {
+	m := newTestMap(3, rand.Perm(200)...)
+
+	left, right := m.Split(120)
+	if m.Size() != 0 || left.Size() != 120 || right.Size() != 80 {
+		t.Fatalf("Expected sizes 0, 120 and 80, got %d, %d and %d instead", m.Size(), left.Size(), right.Size())
+	}
+	if left.Max().Key() != 119 || right.Min().Key() != 120 {
+		t.Errorf("Expected the split at 120, got %v and %v instead", left.Max(), right.Min())
+	}
+	for _, part := range []*Map[int, int]{left, right} {
+		if err := part.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMapSetOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       func(a, b *Map[int, int])
+		expected []int
+	}{
+		{name: "Union", op: (*Map[int, int]).Union, expected: []int{1, 2, 3, 4, 5, 6}},
+		{name: "Intersection", op: (*Map[int, int]).Intersection, expected: []int{3, 4}},
+		{name: "Difference", op: (*Map[int, int]).Difference, expected: []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		a := newTestMap(2, 1, 2, 3, 4)
+		b := NewMap[int, int](2)
+		for _, k := range []int{3, 4, 5, 6} {
+			b.Insert(k, -k)
+		}
+
+		tt.op(a, b)
+		if !slices.Equal(a.Keys(), tt.expected) {
+			t.Errorf("%s: Expected %v, got %v instead", tt.name, tt.expected, a.Keys())
+		}
+		if b.Size() != 0 {
+			t.Errorf("%s: Expected the argument to be empty, got size %d instead", tt.name, b.Size())
+		}
+		if err := a.Validate(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+	}
+
+	a, b := newTestMap(2, 1, 2, 3), newTestMap(2, 3)
+	b.Put(3, 30)
+	a.Union(b)
+	if v, _ := a.Get(3); v != 30 {
+		t.Errorf("Expected Union() to take the value of the argument, got %d instead", v)
+	}
+
+	self := newTestMap(2, 1, 2, 3)
+	self.Union(self)
+	self.Intersection(self)
+	if self.Size() != 3 {
+		t.Errorf("Expected Union() and Intersection() with itself to keep 3 keys, got %d instead", self.Size())
+	}
+	self.Difference(self)
+	if self.Size() != 0 {
+		t.Errorf("Expected Difference() with itself to empty the map, got size %d instead", self.Size())
+	}
+}
+
+func TestMapDelete(t *testing.T) {
+	m := newTestMap(2, rand.Perm(100)...)
+
+	if n := m.DeleteRange(10, 19); n != 10 {
+		t.Errorf("Expected DeleteRange() to remove 10 keys, got %d instead", n)
+	}
+	if n := m.DeleteFunc(func(k, _ int) bool { return k%2 == 1 }); n != 45 {
+		t.Errorf("Expected DeleteFunc() to remove 45 keys, got %d instead", n)
+	}
+	if m.Size() != 45 || m.CountRange(10, 19) != 0 {
+		t.Errorf("Expected 45 keys outside [10, 19], got %v instead", m.Keys())
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	other := m.Clone()
+	eq := func(a, b int) bool { return a == b }
+	if !m.Equal(other, eq) {
+		t.Error("Expected a clone to be equal")
+	}
+	other.Put(0, 1)
+	if m.Equal(other, eq) {
+		t.Error("Expected maps with different values not to be equal")
+	}
+}
+
+func TestMapEncoding(t *testing.T) {
+	m := newTestMap(2, rand.Perm(100)...)
+	eq := func(a, b int) bool { return a == b }
+
+	data, err := m.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON Map[int, int]
+	if err := fromJSON.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Equal(&fromJSON, eq) || fromJSON.Validate() != nil {
+		t.Errorf("Expected the JSON round trip to keep the map, got %v instead", fromJSON.Keys())
+	}
+
+	if err := fromJSON.UnmarshalJSON([]byte(`[{"key":2,"value":1},{"key":1,"value":1},{"key":2,"value":3}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := fromJSON.Get(2); fromJSON.Size() != 2 || v != 3 {
+		t.Errorf("Expected unsorted pairs to be inserted in order, got %v instead", fromJSON.Keys())
+	}
+
+	data, err = m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary Map[int, int]
+	if err := fromBinary.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Equal(&fromBinary, eq) || fromBinary.Validate() != nil {
+		t.Errorf("Expected the binary round trip to keep the map, got %v instead", fromBinary.Keys())
+	}
+
+	data[0] = 99
+	if err := fromBinary.UnmarshalBinary(data); err == nil {
+		t.Error("Expected an error for an unknown version, got nil instead")
+	}
+}
+
+func TestMapCursor(t *testing.T) {
+	m := newTestMap(2, rand.Perm(50)...)
+	c := m.Cursor()
+
+	var got []int
+	for ok := c.Last(); ok; ok = c.Prev() {
+		got = append(got, c.Key())
+	}
+	if len(got) != 50 || got[0] != 49 || got[49] != 0 {
+		t.Errorf("Expected keys 49 down to 0, got %v instead", got)
+	}
+
+	for ok := c.Seek(10); ok && c.Key() < 20; {
+		ok = c.Delete()
+	}
+	if m.Size() != 40 || m.CountRange(10, 19) != 0 {
+		t.Errorf("Expected Delete() to remove [10, 19], got %v instead", m.Keys())
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.First()
+	m.Insert(100, 100)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected to recover from panic after a modification, got nil instead")
+		}
+	}()
+	c.Next()
+}
+
+func TestMapFormat(t *testing.T) {
+	m := newTestMap(2, 1, 2, 3, 4, 5, 6)
+
+	var buf bytes.Buffer
+	if err := m.Format(&buf, FormatOptions{ShowSize: true}); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+	expected := "" +
+		"[2 4] n=6\n" +
+		"|-- [1] n=1\n" +
+		"|-- [3] n=1\n" +
+		"`-- [5 6] n=2\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	m.Format(&buf, FormatOptions{MaxDepth: 1})
+	if s := buf.String(); s != "[2 4] (+4 more)\n" {
+		t.Errorf("Expected depth-limited output \"[2 4] (+4 more)\", got %q instead", s)
+	}
+
+	buf.Reset()
+	if err := m.WriteDOT(&buf); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+	out := buf.String()
+	for _, s := range []string{
+		"digraph btree {\n",
+		`n0 [label="<c0>|2|<c1>|4|<c2>"];`,
+		`n1 [label="1"];`,
+		"n0:c2 -> n3;",
+		`n3 [label="5|6"];`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected DOT output to contain %q, got:\n%s", s, out)
+		}
+	}
+}
+
+const benchSize = 100000
+
+func benchKeys() []int {
+	r := rand.New(rand.NewPCG(1, 2))
+	return r.Perm(benchSize)
+}
+
+func BenchmarkInsert(b *testing.B) {
+	keys := benchKeys()
+
+	b.Run("avl", func(b *testing.B) {
+		for range b.N {
+			tree := bst.New[int, int]()
+			for _, k := range keys {
+				tree.Insert(k, k)
+			}
+		}
+	})
+	for _, degree := range []int{4, 16, 64} {
+		b.Run(fmt.Sprintf("btree-%d", degree), func(b *testing.B) {
+			for range b.N {
+				m := NewMap[int, int](degree)
+				for _, k := range keys {
+					m.Insert(k, k)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkFind measures lookups with Get, since Map.Find allocates a copy of
+// the entry on every hit.
+func BenchmarkFind(b *testing.B) {
+	keys := benchKeys()
+
+	b.Run("avl", func(b *testing.B) {
+		tree := bst.New[int, int]()
+		for _, k := range keys {
+			tree.Insert(k, k)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := range b.N {
+			tree.Get(keys[i%benchSize])
+		}
+	})
+	for _, degree := range []int{4, 16, 64} {
+		b.Run(fmt.Sprintf("btree-%d", degree), func(b *testing.B) {
+			m := NewMap[int, int](degree)
+			for _, k := range keys {
+				m.Insert(k, k)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				m.Get(keys[i%benchSize])
+			}
+		})
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	keys := benchKeys()
+
+	b.Run("avl", func(b *testing.B) {
+		tree := bst.New[int, int]()
+		for _, k := range keys {
+			tree.Insert(k, k)
+		}
+		b.ResetTimer()
+		for range b.N {
+			for range tree.All() {
+			}
+		}
+	})
+	for _, degree := range []int{4, 16, 64} {
+		b.Run(fmt.Sprintf("btree-%d", degree), func(b *testing.B) {
+			m := NewMap[int, int](degree)
+			for _, k := range keys {
+				m.Insert(k, k)
+			}
+			b.ResetTimer()
+			for range b.N {
+				for range m.All() {
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import "cmp"
+
+// Cursor is a position in a map that can move in both directions and delete
+// the entry it points to. Any structural modification of the map that is not
+// made through the cursor invalidates it: moving, reading or deleting through
+// an invalidated cursor panics until it is repositioned with First, Last or Seek.
+type Cursor[K cmp.Ordered, V any] struct {
+	m    *Map[K, V]
+	n    *mapNode[K, V]
+	i    int
+	mods int
+}
+
+// Cursor returns a cursor over the map that is not positioned at any entry.
+func (m *Map[K, V]) Cursor() *Cursor[K, V] {
+	if m == nil {
+		panic("btree: called Cursor() on a nil map")
+	}
+	return &Cursor[K, V]{m: m, mods: m.mods}
+}
+
+// First moves the cursor to the smallest key and reports whether it exists.
+func (c *Cursor[K, V]) First() bool {
+	c.mods = c.m.mods
+	c.n, c.i = nil, 0
+	if n := c.m.root; n != nil {
+		for !n.leaf() {
+			n = n.children[0]
+		}
+		c.n = n
+	}
+	return c.n != nil
+}
+
+// Last moves the cursor to the greatest key and reports whether it exists.
+func (c *Cursor[K, V]) Last() bool {
+	c.mods = c.m.mods
+	c.n, c.i = nil, 0
+	if n := c.m.root; n != nil {
+		for !n.leaf() {
+			n = n.children[len(n.children)-1]
+		}
+		c.n, c.i = n, len(n.keys)-1
+	}
+	return c.n != nil
+}
+
+// Seek moves the cursor to the smallest key greater than or equal to key and
+// reports whether it exists.
+func (c *Cursor[K, V]) Seek(key K) bool {
+	c.mods = c.m.mods
+	c.n, c.i = c.m.ceiling(key, true)
+	return c.n != nil
+}
+
+// Next moves the cursor to the next key and reports whether it exists.
+func (c *Cursor[K, V]) Next() bool {
+	c.checkValid("Next")
+	c.n, c.i = c.m.ceiling(c.n.keys[c.i], false)
+	return c.n != nil
+}
+
+// Prev moves the cursor to the previous key and reports whether it exists.
+func (c *Cursor[K, V]) Prev() bool {
+	c.checkValid("Prev")
+	c.n, c.i = c.m.floor(c.n.keys[c.i], false)
+	return c.n != nil
+}
+
+// Valid reports whether the cursor points to an entry.
+func (c *Cursor[K, V]) Valid() bool {
+	return c.n != nil && c.mods == c.m.mods
+}
+
+func (c *Cursor[K, V]) Key() K {
+	c.checkValid("Key")
+	return c.n.keys[c.i]
+}
+
+func (c *Cursor[K, V]) Value() V {
+	c.checkValid("Value")
+	return c.n.vals[c.i]
+}
+
+// Delete removes the current entry and moves the cursor to the next key. It
+// reports whether the cursor points to an entry afterwards.
+func (c *Cursor[K, V]) Delete() bool {
+	c.checkValid("Delete")
+
+	key := c.n.keys[c.i]
+	c.m.delete(key)
+	c.mods = c.m.mods
+	c.n, c.i = c.m.ceiling(key, false)
+	return c.n != nil
+}
+
+func (c *Cursor[K, V]) checkValid(method string) {
+	if c.mods != c.m.mods {
+		panic("btree: called " + method + "() on a cursor after the map was modified")
+	}
+	if c.n == nil {
+		panic("btree: called " + method + "() on a cursor that is not positioned")
+	}
+}
@@ -0,0 +1,65 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+// DeleteRange removes every key with lo <= key <= hi and returns how many keys
+// were removed. The keys are removed one by one, so it runs in O(k log n) time
+// for k removed keys.
+func (m *Map[K, V]) DeleteRange(lo, hi K) int {
+	if m == nil {
+		panic("btree: called DeleteRange() on a nil map")
+	}
+
+	var keys []K
+	for key := range m.Range(lo, hi) {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		m.delete(key)
+	}
+	return len(keys)
+}
+
+// DeleteFunc removes every entry for which pred returns true and returns how
+// many entries were removed. The remaining entries are loaded into a new tree
+// in a single O(n) pass instead of being removed one by one.
+func (m *Map[K, V]) DeleteFunc(pred func(key K, val V) bool) int {
+	if m == nil {
+		panic("btree: called DeleteFunc() on a nil map")
+	}
+
+	keys, vals := m.entries()
+	keepKeys, keepVals := keys[:0], vals[:0]
+	for i, key := range keys {
+		if !pred(key, vals[i]) {
+			keepKeys = append(keepKeys, key)
+			keepVals = append(keepVals, vals[i])
+		}
+	}
+
+	n := m.size - len(keepKeys)
+	if n == 0 {
+		return 0
+	}
+	m.load(keepKeys, keepVals)
+	return n
+}
@@ -0,0 +1,140 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// binaryVersion is the version of the format written by Map.MarshalBinary. It
+// is stored in the first byte of the encoding.
+const binaryVersion = 1
+
+// jsonEntry is the JSON representation of a key-value pair.
+type jsonEntry[K any, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// binaryEntries is the gob payload of the binary encoding.
+type binaryEntries[K any, V any] struct {
+	Keys []K
+	Vals []V
+}
+
+// MarshalJSON encodes the map as an array of {"key": ..., "value": ...}
+// objects in ascending key order, the same encoding as bst.Tree.
+func (m *Map[K, V]) MarshalJSON() ([]byte, error) {
+	out := make([]jsonEntry[K, V], 0, m.Size())
+	for k, v := range m.All() {
+		out = append(out, jsonEntry[K, V]{Key: k, Value: v})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON replaces the contents of the map with the decoded pairs. Pairs
+// in ascending key order are loaded in linear time; otherwise they are
+// inserted one by one and later duplicates replace earlier ones.
+func (m *Map[K, V]) UnmarshalJSON(data []byte) error {
+	if m == nil {
+		panic("btree: called UnmarshalJSON() on a nil map")
+	}
+
+	var in []jsonEntry[K, V]
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	keys := make([]K, len(in))
+	vals := make([]V, len(in))
+	for i, e := range in {
+		keys[i], vals[i] = e.Key, e.Value
+	}
+
+	if isSorted(keys) {
+		m.load(keys, vals)
+		return nil
+	}
+
+	m.clear()
+	for i := range keys {
+		m.Put(keys[i], vals[i])
+	}
+	return nil
+}
+
+// MarshalBinary encodes the map as a version byte followed by the gob
+// encoding of its keys and values in ascending key order.
+func (m *Map[K, V]) MarshalBinary() ([]byte, error) {
+	var payload binaryEntries[K, V]
+	if m != nil {
+		payload.Keys, payload.Vals = m.entries()
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(binaryVersion)
+	if err := gob.NewEncoder(&buf).Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the contents of the map with data produced by MarshalBinary.
+func (m *Map[K, V]) UnmarshalBinary(data []byte) error {
+	if m == nil {
+		panic("btree: called UnmarshalBinary() on a nil map")
+	}
+	if len(data) == 0 {
+		return errors.New("btree: empty binary encoding")
+	}
+	if data[0] != binaryVersion {
+		return fmt.Errorf("btree: unsupported binary encoding version %d", data[0])
+	}
+
+	var payload binaryEntries[K, V]
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&payload); err != nil {
+		return err
+	}
+	if len(payload.Keys) != len(payload.Vals) {
+		return errors.New("btree: corrupted binary encoding: key and value counts differ")
+	}
+	if !isSorted(payload.Keys) {
+		return errors.New("btree: corrupted binary encoding: keys are not in ascending order")
+	}
+	m.load(payload.Keys, payload.Vals)
+	return nil
+}
+
+// isSorted reports whether keys are in strictly ascending order.
+func isSorted[K cmp.Ordered](keys []K) bool {
+	for i := 1; i < len(keys); i++ {
+		if cmp.Compare(keys[i-1], keys[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
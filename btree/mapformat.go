@@ -0,0 +1,118 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FormatOptions controls the output of Map.Format.
+type FormatOptions struct {
+	// MaxDepth limits the number of levels drawn. Children below the limit
+	// are replaced by a count of their keys. Zero means no limit.
+	MaxDepth int
+	// ShowSize appends the number of keys in the subtree of every node.
+	ShowSize bool
+}
+
+// WriteDOT writes the structure of the map in the Graphviz DOT language. Every
+// node is drawn as a record of its keys, with a port between each pair of
+// keys for the child that holds the keys between them.
+func (m *Map[K, V]) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph btree {")
+	fmt.Fprintln(bw, "\tnode [shape=record, fontname=\"monospace\"];")
+
+	if m != nil && m.root != nil {
+		id := 0
+		var walk func(n *mapNode[K, V]) int
+		walk = func(n *mapNode[K, V]) int {
+			self := id
+			id++
+
+			fields := make([]string, 0, 2*len(n.keys)+1)
+			for i, key := range n.keys {
+				if !n.leaf() {
+					fields = append(fields, fmt.Sprintf("<c%d>", i))
+				}
+				fields = append(fields, escapeRecord(fmt.Sprintf("%v", key)))
+			}
+			if !n.leaf() {
+				fields = append(fields, fmt.Sprintf("<c%d>", len(n.keys)))
+			}
+			fmt.Fprintf(bw, "\tn%d [label=\"%s\"];\n", self, strings.Join(fields, "|"))
+
+			for i, child := range n.children {
+				fmt.Fprintf(bw, "\tn%d:c%d -> n%d;\n", self, i, walk(child))
+			}
+			return self
+		}
+		walk(m.root)
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// Format draws the map top-down with every node on its own line, its keys in
+// brackets and its children indented below it in ascending order.
+func (m *Map[K, V]) Format(w io.Writer, opts FormatOptions) error {
+	bw := bufio.NewWriter(w)
+	if m != nil && m.root != nil {
+		formatMapNode(bw, m.root, "", "", 1, opts)
+	}
+	return bw.Flush()
+}
+
+// formatMapNode writes the subtree of n with the given prefixes: line is used
+// for the node itself and below for the lines of its children.
+func formatMapNode[K cmp.Ordered, V any](w *bufio.Writer, n *mapNode[K, V], line, below string, depth int, opts FormatOptions) {
+	label := fmt.Sprintf("%v", n.keys)
+	if opts.ShowSize {
+		label += fmt.Sprintf(" n=%d", n.size)
+	}
+
+	if opts.MaxDepth > 0 && depth >= opts.MaxDepth && !n.leaf() {
+		fmt.Fprintf(w, "%s%s (+%d more)\n", line, label, n.size-len(n.keys))
+		return
+	}
+
+	fmt.Fprintf(w, "%s%s\n", line, label)
+	for i, child := range n.children {
+		if i < len(n.children)-1 {
+			formatMapNode(w, child, below+"|-- ", below+"|   ", depth+1, opts)
+		} else {
+			formatMapNode(w, child, below+"`-- ", below+"    ", depth+1, opts)
+		}
+	}
+}
+
+// escapeRecord escapes the characters that have a meaning in the labels of
+// record nodes.
+func escapeRecord(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`,
+		"|", `\|`, "{", `\{`, "}", `\}`, "<", `\<`, ">", `\>`).Replace(s)
+}
@@ -0,0 +1,431 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"cmp"
+	"slices"
+)
+
+// mapNode is a node of a Map. Leaves have nil children. The size of a node is
+// the number of keys in its subtree, which lets Rank and Select skip whole
+// children.
+type mapNode[K cmp.Ordered, V any] struct {
+	keys     []K
+	vals     []V
+	children []*mapNode[K, V]
+	size     int
+}
+
+func (m *Map[K, V]) maxKeys() int {
+	return 2*m.degree - 1
+}
+
+// newNode allocates a node with room for the maximum number of keys, so it
+// never has to grow.
+func (m *Map[K, V]) newNode(leaf bool) *mapNode[K, V] {
+	n := &mapNode[K, V]{
+		keys: make([]K, 0, m.maxKeys()),
+		vals: make([]V, 0, m.maxKeys()),
+	}
+	if !leaf {
+		n.children = make([]*mapNode[K, V], 0, m.maxKeys()+1)
+	}
+	return n
+}
+
+func (n *mapNode[K, V]) leaf() bool {
+	return n.children == nil
+}
+
+func (n *mapNode[K, V]) search(key K) (int, bool) {
+	return slices.BinarySearch(n.keys, key)
+}
+
+// recount recomputes the size of n from its keys and children.
+func (n *mapNode[K, V]) recount() {
+	n.size = len(n.keys)
+	for _, child := range n.children {
+		n.size += child.size
+	}
+}
+
+func (n *mapNode[K, V]) removeAt(i int) {
+	n.keys = slices.Delete(n.keys, i, i+1)
+	n.vals = slices.Delete(n.vals, i, i+1)
+}
+
+// entry returns a copy of the i-th entry of n, or nil if n is nil.
+func entry[K cmp.Ordered, V any](n *mapNode[K, V], i int) *Node[K, V] {
+	if n == nil {
+		return nil
+	}
+	return &Node[K, V]{key: n.keys[i], data: n.vals[i]}
+}
+
+// find returns the node holding key and the index of key in it.
+func (m *Map[K, V]) find(key K) (*mapNode[K, V], int) {
+	n := m.root
+	for n != nil {
+		i, found := n.search(key)
+		if found {
+			return n, i
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	return nil, 0
+}
+
+func (m *Map[K, V]) floor(key K, inclusive bool) (*mapNode[K, V], int) {
+	var best *mapNode[K, V]
+	bi := 0
+
+	n := m.root
+	for n != nil {
+		i, found := n.search(key)
+		if found && inclusive {
+			return n, i
+		}
+		if i > 0 {
+			best, bi = n, i-1
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	return best, bi
+}
+
+func (m *Map[K, V]) ceiling(key K, inclusive bool) (*mapNode[K, V], int) {
+	var best *mapNode[K, V]
+	bi := 0
+
+	n := m.root
+	for n != nil {
+		i, found := n.search(key)
+		if found {
+			if inclusive {
+				return n, i
+			}
+			i++
+		}
+		if i < len(n.keys) {
+			best, bi = n, i
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	return best, bi
+}
+
+// insert adds key in a single pass from the root, splitting every full node
+// on the way down so that a split never has to propagate upwards.
+func (m *Map[K, V]) insert(key K, val V, replace bool) (old V, replaced bool) {
+	if m.degree == 0 {
+		m.degree = DefaultDegree
+	}
+	if m.root == nil {
+		m.root = m.newNode(true)
+	}
+	if len(m.root.keys) == m.maxKeys() {
+		root := m.newNode(false)
+		root.children = append(root.children, m.root)
+		m.splitChild(root, 0)
+		root.recount()
+		m.root = root
+	}
+
+	n := m.root
+	for {
+		i, found := n.search(key)
+		if found {
+			m.adjust(key, n, -1)
+			if !replace {
+				return old, false
+			}
+			old, n.vals[i] = n.vals[i], val
+			return old, true
+		}
+		if n.leaf() {
+			n.keys = slices.Insert(n.keys, i, key)
+			n.vals = slices.Insert(n.vals, i, val)
+			n.size++
+			m.size++
+			m.mods++
+			return old, false
+		}
+		if len(n.children[i].keys) == m.maxKeys() {
+			m.splitChild(n, i)
+			continue
+		}
+		n.size++
+		n = n.children[i]
+	}
+}
+
+// adjust adds delta to the size of every node on the path from the root to key,
+// stopping before stop. insert and remove count the key on their way down and
+// use it to undo the counts when the key turns out to be present or missing.
+func (m *Map[K, V]) adjust(key K, stop *mapNode[K, V], delta int) {
+	for n := m.root; n != stop; {
+		n.size += delta
+		if n.leaf() {
+			return
+		}
+		i, _ := n.search(key)
+		n = n.children[i]
+	}
+}
+
+// splitChild moves the upper half of the full i-th child of parent into a new
+// node and its median key into parent.
+func (m *Map[K, V]) splitChild(parent *mapNode[K, V], i int) {
+	t := m.degree
+	child := parent.children[i]
+	right := m.newNode(child.leaf())
+
+	right.keys = append(right.keys, child.keys[t:]...)
+	right.vals = append(right.vals, child.vals[t:]...)
+	if !child.leaf() {
+		right.children = append(right.children, child.children[t:]...)
+		clear(child.children[t:])
+		child.children = child.children[:t]
+	}
+
+	parent.keys = slices.Insert(parent.keys, i, child.keys[t-1])
+	parent.vals = slices.Insert(parent.vals, i, child.vals[t-1])
+	parent.children = slices.Insert(parent.children, i+1, right)
+
+	clear(child.keys[t-1:])
+	clear(child.vals[t-1:])
+	child.keys = child.keys[:t-1]
+	child.vals = child.vals[:t-1]
+	child.recount()
+	right.recount()
+}
+
+func (m *Map[K, V]) delete(key K) (val V, ok bool) {
+	if m.root == nil {
+		return val, false
+	}
+	val, ok = m.remove(m.root, key)
+	if ok {
+		m.size--
+		m.mods++
+	}
+	m.shrink()
+	return val, ok
+}
+
+// remove deletes key in a single pass from n, making sure every node it
+// descends into has at least degree keys, so that removing one from it never
+// leaves it underfull.
+func (m *Map[K, V]) remove(n *mapNode[K, V], key K) (val V, ok bool) {
+	for {
+		n.size--
+		i, found := n.search(key)
+		if n.leaf() {
+			if !found {
+				m.adjust(key, nil, 1)
+				return val, false
+			}
+			val = n.vals[i]
+			n.removeAt(i)
+			return val, true
+		}
+
+		if !found {
+			n = n.children[m.fill(n, i)]
+			continue
+		}
+
+		// Replace the key with its predecessor or successor if either child
+		// can spare a key, otherwise merge the children and remove it from there.
+		val = n.vals[i]
+		switch {
+		case len(n.children[i].keys) >= m.degree:
+			n.keys[i], n.vals[i] = m.deleteMax(n.children[i])
+			return val, true
+		case len(n.children[i+1].keys) >= m.degree:
+			n.keys[i], n.vals[i] = m.deleteMin(n.children[i+1])
+			return val, true
+		}
+		m.merge(n, i)
+		n = n.children[i]
+	}
+}
+
+// deleteMin and deleteMax remove an entry from the subtree of n, which must
+// have at least degree keys unless it is the root.
+func (m *Map[K, V]) deleteMin(n *mapNode[K, V]) (K, V) {
+	for !n.leaf() {
+		n.size--
+		n = n.children[m.fill(n, 0)]
+	}
+	n.size--
+	key, val := n.keys[0], n.vals[0]
+	n.removeAt(0)
+	return key, val
+}
+
+func (m *Map[K, V]) deleteMax(n *mapNode[K, V]) (K, V) {
+	for !n.leaf() {
+		n.size--
+		n = n.children[m.fill(n, len(n.children)-1)]
+	}
+	n.size--
+	last := len(n.keys) - 1
+	key, val := n.keys[last], n.vals[last]
+	n.removeAt(last)
+	return key, val
+}
+
+// fill makes sure the i-th child of n has at least degree keys by borrowing
+// a key from a sibling or merging with one. It returns the index of the child
+// that covers the keys of the original one.
+func (m *Map[K, V]) fill(n *mapNode[K, V], i int) int {
+	child := n.children[i]
+	if len(child.keys) >= m.degree {
+		return i
+	}
+
+	if i > 0 && len(n.children[i-1].keys) >= m.degree {
+		left := n.children[i-1]
+		last := len(left.keys) - 1
+		child.keys = slices.Insert(child.keys, 0, n.keys[i-1])
+		child.vals = slices.Insert(child.vals, 0, n.vals[i-1])
+		n.keys[i-1], n.vals[i-1] = left.keys[last], left.vals[last]
+		left.removeAt(last)
+		if !child.leaf() {
+			child.children = slices.Insert(child.children, 0, left.children[last+1])
+			left.children = slices.Delete(left.children, last+1, last+2)
+		}
+		child.recount()
+		left.recount()
+		return i
+	}
+
+	if i < len(n.keys) && len(n.children[i+1].keys) >= m.degree {
+		right := n.children[i+1]
+		child.keys = append(child.keys, n.keys[i])
+		child.vals = append(child.vals, n.vals[i])
+		n.keys[i], n.vals[i] = right.keys[0], right.vals[0]
+		right.removeAt(0)
+		if !child.leaf() {
+			child.children = append(child.children, right.children[0])
+			right.children = slices.Delete(right.children, 0, 1)
+		}
+		child.recount()
+		right.recount()
+		return i
+	}
+
+	if i == len(n.keys) {
+		i--
+	}
+	m.merge(n, i)
+	return i
+}
+
+// merge moves the i-th key of n and all of its (i+1)-th child into the i-th child.
+func (m *Map[K, V]) merge(n *mapNode[K, V], i int) {
+	left, right := n.children[i], n.children[i+1]
+	left.keys = append(append(left.keys, n.keys[i]), right.keys...)
+	left.vals = append(append(left.vals, n.vals[i]), right.vals...)
+	if !left.leaf() {
+		left.children = append(left.children, right.children...)
+	}
+	n.removeAt(i)
+	n.children = slices.Delete(n.children, i+1, i+2)
+	left.recount()
+}
+
+// shrink drops the root once it has no keys left.
+func (m *Map[K, V]) shrink() {
+	if m.root == nil || len(m.root.keys) > 0 {
+		return
+	}
+	if m.root.leaf() {
+		m.root = nil
+	} else {
+		m.root = m.root.children[0]
+	}
+}
+
+func (m *Map[K, V]) cloneNode(n *mapNode[K, V]) *mapNode[K, V] {
+	if n == nil {
+		return nil
+	}
+	c := m.newNode(n.leaf())
+	c.keys = append(c.keys, n.keys...)
+	c.vals = append(c.vals, n.vals...)
+	c.size = n.size
+	for _, child := range n.children {
+		c.children = append(c.children, m.cloneNode(child))
+	}
+	return c
+}
+
+func (n *mapNode[K, V]) walk(yield func(K, V) bool) bool {
+	for i := range n.keys {
+		if !n.leaf() && !n.children[i].walk(yield) {
+			return false
+		}
+		if !yield(n.keys[i], n.vals[i]) {
+			return false
+		}
+	}
+	return n.leaf() || n.children[len(n.keys)].walk(yield)
+}
+
+func (n *mapNode[K, V]) walkBackward(yield func(K, V) bool) bool {
+	for i := len(n.keys) - 1; i >= 0; i-- {
+		if !n.leaf() && !n.children[i+1].walkBackward(yield) {
+			return false
+		}
+		if !yield(n.keys[i], n.vals[i]) {
+			return false
+		}
+	}
+	return n.leaf() || n.children[0].walkBackward(yield)
+}
+
+// walkRange yields the entries with lo <= key <= hi and returns false once it
+// has passed hi or yield has asked to stop.
+func (n *mapNode[K, V]) walkRange(lo, hi K, yield func(K, V) bool) bool {
+	i, _ := n.search(lo)
+	for ; i < len(n.keys); i++ {
+		if !n.leaf() && !n.children[i].walkRange(lo, hi, yield) {
+			return false
+		}
+		if cmp.Compare(n.keys[i], hi) > 0 || !yield(n.keys[i], n.vals[i]) {
+			return false
+		}
+	}
+	return n.leaf() || n.children[len(n.keys)].walkRange(lo, hi, yield)
+}
@@ -0,0 +1,98 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import "cmp"
+
+// Rank returns the number of keys in the map that are less than key.
+func (m *Map[K, V]) Rank(key K) int {
+	if m == nil {
+		return 0
+	}
+	return m.rank(key, false)
+}
+
+// Select returns the entry with the i-th smallest key, counting from 0.
+func (m *Map[K, V]) Select(i int) *Node[K, V] {
+	if m == nil {
+		panic("btree: called Select() on a nil map")
+	}
+	if i < 0 || i >= m.size {
+		panic("btree: called Select() with invalid index")
+	}
+
+	n := m.root
+next:
+	for {
+		for j := range n.keys {
+			if !n.leaf() {
+				child := n.children[j]
+				if i < child.size {
+					n = child
+					continue next
+				}
+				i -= child.size
+			}
+			if i == 0 {
+				return entry(n, j)
+			}
+			i--
+		}
+		n = n.children[len(n.keys)]
+	}
+}
+
+// CountRange returns the number of keys with lo <= key <= hi.
+func (m *Map[K, V]) CountRange(lo, hi K) int {
+	if m == nil || m.root == nil || cmp.Compare(lo, hi) > 0 {
+		return 0
+	}
+	return m.rank(hi, true) - m.rank(lo, false)
+}
+
+// rank counts the keys below key, including key itself when inclusive is set.
+func (m *Map[K, V]) rank(key K, inclusive bool) int {
+	r := 0
+	for n := m.root; n != nil; {
+		i, found := n.search(key)
+		r += i
+		if !n.leaf() {
+			for _, child := range n.children[:i] {
+				r += child.size
+			}
+		}
+		if found {
+			if !n.leaf() {
+				r += n.children[i].size
+			}
+			if inclusive {
+				r++
+			}
+			return r
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	return r
+}
@@ -0,0 +1,219 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// Split moves the keys less than key into left and the remaining keys into
+// right, leaving m empty. Both maps have the degree of m. The maps are rebuilt
+// from the sorted entries, so Split runs in linear time.
+func (m *Map[K, V]) Split(key K) (left, right *Map[K, V]) {
+	if m == nil {
+		panic("btree: called Split() on a nil map")
+	}
+
+	keys, vals := m.entries()
+	i, _ := slices.BinarySearch(keys, key)
+	left = &Map[K, V]{degree: m.degree}
+	right = &Map[K, V]{degree: m.degree}
+	left.load(keys[:i], vals[:i])
+	right.load(keys[i:], vals[i:])
+	m.root, m.size = nil, 0
+	m.mods++
+	return left, right
+}
+
+// Union moves every entry of other into m, leaving other empty. Values from
+// other replace the values of keys present in both maps. The union of a map
+// with itself leaves it unchanged.
+func (m *Map[K, V]) Union(other *Map[K, V]) {
+	if m == nil || other == nil {
+		panic("btree: called Union() with a nil map")
+	}
+	if m == other {
+		return
+	}
+
+	aKeys, aVals := m.entries()
+	bKeys, bVals := other.entries()
+	keys := make([]K, 0, len(aKeys)+len(bKeys))
+	vals := make([]V, 0, len(aKeys)+len(bKeys))
+	i, j := 0, 0
+	for i < len(aKeys) || j < len(bKeys) {
+		switch {
+		case j == len(bKeys) || (i < len(aKeys) && cmp.Compare(aKeys[i], bKeys[j]) < 0):
+			keys, vals = append(keys, aKeys[i]), append(vals, aVals[i])
+			i++
+		case i == len(aKeys) || cmp.Compare(aKeys[i], bKeys[j]) > 0:
+			keys, vals = append(keys, bKeys[j]), append(vals, bVals[j])
+			j++
+		default:
+			keys, vals = append(keys, bKeys[j]), append(vals, bVals[j])
+			i++
+			j++
+		}
+	}
+
+	m.load(keys, vals)
+	other.clear()
+}
+
+// Intersection keeps only the keys of m that are also present in other,
+// leaving other empty. The intersection of a map with itself leaves it
+// unchanged.
+func (m *Map[K, V]) Intersection(other *Map[K, V]) {
+	if m == nil || other == nil {
+		panic("btree: called Intersection() with a nil map")
+	}
+	if m == other {
+		return
+	}
+	m.filter(other, true)
+	other.clear()
+}
+
+// Difference removes from m every key that is present in other, leaving other
+// empty. The difference of a map with itself empties it.
+func (m *Map[K, V]) Difference(other *Map[K, V]) {
+	if m == nil || other == nil {
+		panic("btree: called Difference() with a nil map")
+	}
+	if m == other {
+		m.clear()
+		return
+	}
+	m.filter(other, false)
+	other.clear()
+}
+
+// Equal reports whether both maps hold the same keys and eqV reports their
+// values as equal.
+func (m *Map[K, V]) Equal(other *Map[K, V], eqV func(a, b V) bool) bool {
+	if m.Size() != other.Size() {
+		return false
+	}
+	if m.Size() == 0 {
+		return true
+	}
+
+	next, stop := iter.Pull2(other.All())
+	defer stop()
+	for key, val := range m.All() {
+		otherKey, otherVal, _ := next()
+		if cmp.Compare(key, otherKey) != 0 || !eqV(val, otherVal) {
+			return false
+		}
+	}
+	return true
+}
+
+// filter keeps the entries of m whose keys are present in other if keep is
+// set, and those whose keys are missing from it otherwise.
+func (m *Map[K, V]) filter(other *Map[K, V], keep bool) {
+	aKeys, aVals := m.entries()
+	bKeys, _ := other.entries()
+	keys, vals := aKeys[:0], aVals[:0]
+	j := 0
+	for i, key := range aKeys {
+		for j < len(bKeys) && cmp.Compare(bKeys[j], key) < 0 {
+			j++
+		}
+		if found := j < len(bKeys) && cmp.Compare(bKeys[j], key) == 0; found == keep {
+			keys, vals = append(keys, key), append(vals, aVals[i])
+		}
+	}
+	m.load(keys, vals)
+}
+
+// entries returns the keys and values of the map in ascending key order.
+func (m *Map[K, V]) entries() ([]K, []V) {
+	keys := make([]K, 0, m.size)
+	vals := make([]V, 0, m.size)
+	for key, val := range m.All() {
+		keys = append(keys, key)
+		vals = append(vals, val)
+	}
+	return keys, vals
+}
+
+func (m *Map[K, V]) clear() {
+	m.root, m.size = nil, 0
+	m.mods++
+}
+
+// load replaces the contents of the map with keys and vals, which must be in
+// ascending key order, building the tree bottom-up in linear time.
+func (m *Map[K, V]) load(keys []K, vals []V) {
+	if m.degree == 0 {
+		m.degree = DefaultDegree
+	}
+	m.root, m.size = nil, len(keys)
+	m.mods++
+	if len(keys) == 0 {
+		return
+	}
+
+	// caps[h] is the greatest number of keys a subtree of height h+1 can hold.
+	caps := []int{m.maxKeys()}
+	for caps[len(caps)-1] < len(keys) {
+		caps = append(caps, (caps[len(caps)-1]+1)*2*m.degree-1)
+	}
+	m.root = m.build(keys, vals, caps)
+}
+
+// build returns a subtree of height len(caps) holding keys and vals. It uses
+// as few children as can hold the keys and gives them equal shares, which
+// keeps every node below the root at least half full.
+func (m *Map[K, V]) build(keys []K, vals []V, caps []int) *mapNode[K, V] {
+	n := m.newNode(len(caps) == 1)
+	n.size = len(keys)
+	if n.leaf() {
+		n.keys = append(n.keys, keys...)
+		n.vals = append(n.vals, vals...)
+		return n
+	}
+
+	caps = caps[:len(caps)-1]
+	per := caps[len(caps)-1] + 1
+	children := (len(keys) + per) / per
+	rest := len(keys) - (children - 1)
+
+	start := 0
+	for i := range children {
+		count := rest / children
+		if i < rest%children {
+			count++
+		}
+		n.children = append(n.children, m.build(keys[start:start+count], vals[start:start+count], caps))
+		start += count
+		if i < children-1 {
+			n.keys = append(n.keys, keys[start])
+			n.vals = append(n.vals, vals[start])
+			start++
+		}
+	}
+	return n
+}
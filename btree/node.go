@@ -0,0 +1,252 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+const (
+	kindFree byte = iota
+	kindLeaf
+	kindBranch
+)
+
+const (
+	// Every page starts with its kind, the number of keys and a page link,
+	// and ends with a checksum.
+	pageHeaderSize = 11
+	pageOverhead   = pageHeaderSize + 4
+
+	minPageSize     = 512
+	maxPageSize     = 1 << 16
+	defaultPageSize = 4096
+)
+
+// node is the decoded form of a page. Leaves hold the encoded values of their
+// keys and a link to the next leaf, branches hold len(keys)+1 children where
+// children[i] holds the keys k with keys[i-1] <= k < keys[i]. Free pages only
+// use next, which links them into the free list.
+type node[K any] struct {
+	id       pgid
+	kind     byte
+	keys     []K
+	rawKeys  [][]byte
+	vals     [][]byte
+	children []pgid
+	next     pgid
+}
+
+// size returns the number of bytes the node takes up in a page.
+func (n *node[K]) size() int {
+	sz := pageOverhead
+	if n.kind == kindBranch {
+		sz += 8
+	}
+	for i := range n.rawKeys {
+		sz += n.entrySize(i)
+	}
+	return sz
+}
+
+func (n *node[K]) entrySize(i int) int {
+	if n.kind == kindLeaf {
+		return entrySize(n.rawKeys[i], n.vals[i])
+	}
+	return uvarintLen(len(n.rawKeys[i])) + len(n.rawKeys[i]) + 8
+}
+
+func entrySize(key, val []byte) int {
+	return uvarintLen(len(key)) + len(key) + uvarintLen(len(val)) + len(val)
+}
+
+func uvarintLen(x int) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], uint64(x))
+}
+
+// splitPoint returns the index that divides the entries of the node into two
+// halves of about the same encoded size.
+func (n *node[K]) splitPoint() int {
+	total := 0
+	for i := range n.rawKeys {
+		total += n.entrySize(i)
+	}
+
+	m, acc := 0, 0
+	for m < len(n.rawKeys) && acc < total/2 {
+		acc += n.entrySize(m)
+		m++
+	}
+	// A branch gives up the key at m to its parent, so both sides must keep
+	// at least one key.
+	hi := len(n.rawKeys) - 1
+	if n.kind == kindBranch {
+		hi--
+	}
+	return max(1, min(m, hi))
+}
+
+// cut truncates the node to the entries before m and returns a node with the
+// remaining entries together with the key that separates the two.
+func (n *node[K]) cut(m int) (*node[K], K, []byte) {
+	right := &node[K]{kind: n.kind}
+	var key K
+	var raw []byte
+
+	if n.kind == kindLeaf {
+		right.keys = slices.Clone(n.keys[m:])
+		right.rawKeys = slices.Clone(n.rawKeys[m:])
+		right.vals = slices.Clone(n.vals[m:])
+		right.next = n.next
+		n.vals = slices.Clip(n.vals[:m])
+		key, raw = right.keys[0], right.rawKeys[0]
+	} else {
+		right.keys = slices.Clone(n.keys[m+1:])
+		right.rawKeys = slices.Clone(n.rawKeys[m+1:])
+		right.children = slices.Clone(n.children[m+1:])
+		n.children = slices.Clip(n.children[:m+1])
+		key, raw = n.keys[m], n.rawKeys[m]
+	}
+	n.keys = slices.Clip(n.keys[:m])
+	n.rawKeys = slices.Clip(n.rawKeys[:m])
+
+	return right, key, raw
+}
+
+// concat returns a node with the entries of left followed by the entries of
+// right. Branches also take the separating key from their parent.
+func concat[K any](left, right *node[K], key K, raw []byte) *node[K] {
+	n := &node[K]{id: left.id, kind: left.kind}
+	if left.kind == kindLeaf {
+		n.keys = slices.Concat(left.keys, right.keys)
+		n.rawKeys = slices.Concat(left.rawKeys, right.rawKeys)
+		n.vals = slices.Concat(left.vals, right.vals)
+		n.next = right.next
+	} else {
+		n.keys = slices.Concat(left.keys, []K{key}, right.keys)
+		n.rawKeys = slices.Concat(left.rawKeys, [][]byte{raw}, right.rawKeys)
+		n.children = slices.Concat(left.children, right.children)
+	}
+	return n
+}
+
+func (n *node[K]) encode(buf []byte) {
+	clear(buf)
+	buf[0] = n.kind
+	binary.LittleEndian.PutUint16(buf[1:], uint16(len(n.rawKeys)))
+	binary.LittleEndian.PutUint64(buf[3:], uint64(n.next))
+
+	b := buf[pageHeaderSize:pageHeaderSize]
+	if n.kind == kindBranch {
+		b = binary.LittleEndian.AppendUint64(b, uint64(n.children[0]))
+	}
+	for i, key := range n.rawKeys {
+		b = binary.AppendUvarint(b, uint64(len(key)))
+		b = append(b, key...)
+		if n.kind == kindLeaf {
+			b = binary.AppendUvarint(b, uint64(len(n.vals[i])))
+			b = append(b, n.vals[i]...)
+		} else {
+			b = binary.LittleEndian.AppendUint64(b, uint64(n.children[i+1]))
+		}
+	}
+	seal(buf)
+}
+
+func decodeNode[K any](id pgid, buf []byte, codec Codec[K]) (*node[K], error) {
+	if !verify(buf) {
+		return nil, fmt.Errorf("%w: checksum mismatch on page %d", ErrCorrupt, id)
+	}
+
+	n := &node[K]{
+		id:   id,
+		kind: buf[0],
+		next: pgid(binary.LittleEndian.Uint64(buf[3:])),
+	}
+	count := int(binary.LittleEndian.Uint16(buf[1:]))
+	switch n.kind {
+	case kindFree:
+		return n, nil
+	case kindLeaf:
+		n.vals = make([][]byte, 0, count)
+	case kindBranch:
+		n.children = make([]pgid, 0, count+1)
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %d of page %d", ErrCorrupt, n.kind, id)
+	}
+	n.keys = make([]K, 0, count)
+	n.rawKeys = make([][]byte, 0, count)
+
+	r := &reader{buf: buf[pageHeaderSize : len(buf)-4]}
+	if n.kind == kindBranch {
+		n.children = append(n.children, pgid(r.uint64()))
+	}
+	for range count {
+		raw := r.bytes()
+		if n.kind == kindLeaf {
+			n.vals = append(n.vals, r.bytes())
+		} else {
+			n.children = append(n.children, pgid(r.uint64()))
+		}
+		if r.bad {
+			return nil, fmt.Errorf("%w: truncated entries on page %d", ErrCorrupt, id)
+		}
+
+		key, err := codec.Decode(raw)
+		if err != nil {
+			return nil, fmt.Errorf("btree: decoding key on page %d: %w", id, err)
+		}
+		n.keys = append(n.keys, key)
+		n.rawKeys = append(n.rawKeys, raw)
+	}
+	return n, nil
+}
+
+// reader consumes the entries of a page, recording any overrun in bad.
+type reader struct {
+	buf []byte
+	bad bool
+}
+
+func (r *reader) uint64() uint64 {
+	if len(r.buf) < 8 {
+		r.bad = true
+		return 0
+	}
+	x := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return x
+}
+
+func (r *reader) bytes() []byte {
+	x, n := binary.Uvarint(r.buf)
+	if n <= 0 || x > uint64(len(r.buf)-n) {
+		r.bad = true
+		return nil
+	}
+	b := r.buf[n : n+int(x)]
+	r.buf = r.buf[n+int(x):]
+	return b
+}
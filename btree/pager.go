@@ -0,0 +1,315 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	metaMagic    = "DSBTREE\x00"
+	journalMagic = "DSBTJRNL"
+	version      = 1
+
+	metaSize          = 48
+	journalHeaderSize = 16
+)
+
+var (
+	// ErrCorrupt is returned when a page or the journal fails validation.
+	ErrCorrupt = errors.New("btree: file is corrupt")
+
+	// ErrClosed is returned by operations on a closed tree.
+	ErrClosed = errors.New("btree: tree is closed")
+)
+
+type pgid uint64
+
+// meta is the content of page 0, which describes the rest of the file.
+type meta struct {
+	pageSize int
+	root     pgid
+	count    int
+	npages   pgid
+	freelist pgid
+}
+
+func (m *meta) encode(buf []byte) {
+	copy(buf, metaMagic)
+	binary.LittleEndian.PutUint32(buf[8:], version)
+	binary.LittleEndian.PutUint32(buf[12:], uint32(m.pageSize))
+	binary.LittleEndian.PutUint64(buf[16:], uint64(m.root))
+	binary.LittleEndian.PutUint64(buf[24:], uint64(m.count))
+	binary.LittleEndian.PutUint64(buf[32:], uint64(m.npages))
+	binary.LittleEndian.PutUint64(buf[40:], uint64(m.freelist))
+	seal(buf)
+}
+
+func (m *meta) decode(buf []byte) error {
+	if string(buf[:8]) != metaMagic || !verify(buf) {
+		return fmt.Errorf("%w: invalid meta page", ErrCorrupt)
+	}
+	if v := binary.LittleEndian.Uint32(buf[8:]); v != version {
+		return fmt.Errorf("btree: unsupported file version %d", v)
+	}
+	m.root = pgid(binary.LittleEndian.Uint64(buf[16:]))
+	m.count = int(binary.LittleEndian.Uint64(buf[24:]))
+	m.npages = pgid(binary.LittleEndian.Uint64(buf[32:]))
+	m.freelist = pgid(binary.LittleEndian.Uint64(buf[40:]))
+	return nil
+}
+
+// seal stores the checksum of a page in its last four bytes.
+func seal(buf []byte) {
+	n := len(buf) - 4
+	binary.LittleEndian.PutUint32(buf[n:], crc32.ChecksumIEEE(buf[:n]))
+}
+
+func verify(buf []byte) bool {
+	n := len(buf) - 4
+	return binary.LittleEndian.Uint32(buf[n:]) == crc32.ChecksumIEEE(buf[:n])
+}
+
+// pager reads and writes fixed-size pages. Writes go through a redo journal:
+// the new page images are written and synced to the journal first, then to
+// the file, and the journal is truncated. A crash before the journal is
+// synced loses the whole write, a crash after it is repaired on open by
+// replaying the journal.
+type pager struct {
+	file     *os.File
+	journal  *os.File
+	pageSize int
+	noSync   bool
+}
+
+func openPager(path string, pageSize int, noSync bool) (*pager, *meta, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	journal, err := os.OpenFile(path+"-journal", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	p := &pager{file: file, journal: journal, pageSize: pageSize, noSync: noSync}
+	// Either file may have just been created; its directory entry must be
+	// durable before anything written to it is.
+	if err := p.syncDir(filepath.Dir(path)); err != nil {
+		p.close()
+		return nil, nil, err
+	}
+	m, err := p.open()
+	if err != nil {
+		p.close()
+		return nil, nil, err
+	}
+	return p, m, nil
+}
+
+func (p *pager) open() (*meta, error) {
+	// The journal must be replayed with the page size of the file, not the one
+	// from the options. The header of a new file may be missing or torn by an
+	// interrupted first write, in which case the journal decides.
+	fileSize, _ := p.filePageSize()
+	if err := p.recover(fileSize); err != nil {
+		return nil, err
+	}
+
+	pageSize, err := p.filePageSize()
+	if err != nil {
+		return nil, err
+	}
+	if pageSize == 0 {
+		return &meta{pageSize: p.pageSize}, nil
+	}
+	p.pageSize = pageSize
+
+	buf, err := p.read(0)
+	if err != nil {
+		return nil, err
+	}
+	m := &meta{pageSize: p.pageSize}
+	if err := m.decode(buf); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// filePageSize returns the page size recorded in the meta page, or zero if the
+// file is empty.
+func (p *pager) filePageSize() (int, error) {
+	info, err := p.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() == 0 {
+		return 0, nil
+	}
+
+	head := make([]byte, metaSize)
+	if _, err := p.file.ReadAt(head, 0); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	pageSize := int(binary.LittleEndian.Uint32(head[12:]))
+	if pageSize < minPageSize || pageSize > maxPageSize {
+		return 0, fmt.Errorf("%w: invalid page size %d", ErrCorrupt, pageSize)
+	}
+	return pageSize, nil
+}
+
+func (p *pager) read(id pgid) ([]byte, error) {
+	buf := make([]byte, p.pageSize)
+	if _, err := p.file.ReadAt(buf, int64(id)*int64(p.pageSize)); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: page %d is out of range", ErrCorrupt, id)
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+// write atomically replaces the given pages.
+func (p *pager) write(pages map[pgid][]byte) error {
+	ids := make([]pgid, 0, len(pages))
+	for id := range pages {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	rec := make([]byte, journalHeaderSize, journalHeaderSize+len(ids)*(8+p.pageSize)+4)
+	copy(rec, journalMagic)
+	binary.LittleEndian.PutUint32(rec[8:], uint32(p.pageSize))
+	binary.LittleEndian.PutUint32(rec[12:], uint32(len(ids)))
+	for _, id := range ids {
+		rec = binary.LittleEndian.AppendUint64(rec, uint64(id))
+		rec = append(rec, pages[id]...)
+	}
+	rec = binary.LittleEndian.AppendUint32(rec, crc32.ChecksumIEEE(rec))
+
+	if _, err := p.journal.WriteAt(rec, 0); err != nil {
+		return err
+	}
+	if err := p.sync(p.journal); err != nil {
+		return err
+	}
+	if err := p.apply(ids, pages); err != nil {
+		return err
+	}
+	// A stale journal is harmless, replaying it writes the same pages again.
+	return p.journal.Truncate(0)
+}
+
+func (p *pager) apply(ids []pgid, pages map[pgid][]byte) error {
+	for _, id := range ids {
+		if _, err := p.file.WriteAt(pages[id], int64(id)*int64(p.pageSize)); err != nil {
+			return err
+		}
+	}
+	return p.sync(p.file)
+}
+
+func (p *pager) syncDir(dir string) error {
+	if p.noSync {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	return errors.Join(d.Sync(), d.Close())
+}
+
+func (p *pager) sync(f *os.File) error {
+	if p.noSync {
+		return nil
+	}
+	return f.Sync()
+}
+
+// recover replays a complete journal left by an interrupted write and drops
+// an incomplete one. fileSize is the page size of the file, or zero if it is
+// not known yet; a journal written with another page size is rejected.
+func (p *pager) recover(fileSize int) error {
+	rec, err := io.ReadAll(io.NewSectionReader(p.journal, 0, 1<<62))
+	if err != nil {
+		return err
+	}
+	if len(rec) == 0 {
+		return nil
+	}
+
+	if pageSize, pages, ok := parseJournal(rec); ok {
+		if fileSize != 0 && pageSize != fileSize {
+			return fmt.Errorf("%w: journal page size %d does not match file page size %d", ErrCorrupt, pageSize, fileSize)
+		}
+		p.pageSize = pageSize
+		ids := make([]pgid, 0, len(pages))
+		for id := range pages {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		if err := p.apply(ids, pages); err != nil {
+			return err
+		}
+	}
+	if err := p.journal.Truncate(0); err != nil {
+		return err
+	}
+	return p.sync(p.journal)
+}
+
+func parseJournal(rec []byte) (int, map[pgid][]byte, bool) {
+	if len(rec) < journalHeaderSize+4 || string(rec[:8]) != journalMagic {
+		return 0, nil, false
+	}
+	pageSize := int(binary.LittleEndian.Uint32(rec[8:]))
+	count := int(binary.LittleEndian.Uint32(rec[12:]))
+	if pageSize < minPageSize || pageSize > maxPageSize {
+		return 0, nil, false
+	}
+	// The journal is truncated rather than rewritten, so anything past the
+	// record is ignored.
+	n := journalHeaderSize + count*(8+pageSize)
+	if len(rec) < n+4 || binary.LittleEndian.Uint32(rec[n:]) != crc32.ChecksumIEEE(rec[:n]) {
+		return 0, nil, false
+	}
+
+	pages := make(map[pgid][]byte, count)
+	for off := journalHeaderSize; off < n; off += 8 + pageSize {
+		id := pgid(binary.LittleEndian.Uint64(rec[off:]))
+		pages[id] = rec[off+8 : off+8+pageSize]
+	}
+	return pageSize, pages, true
+}
+
+func (p *pager) close() error {
+	return errors.Join(p.journal.Close(), p.file.Close())
+}
@@ -0,0 +1,381 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+// Package durable implements an ordered map that survives crashes.
+//
+// A Map keeps its entries in a bst.Tree. Every change is appended to a log
+// file, with a checksum, before it is applied to the tree, and opening the map
+// replays the log. Once the log has grown by a number of records, the tree is
+// written to a snapshot file and the log is emptied.
+package durable
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+
+	"github.com/nmezhenskyi/ds/bst"
+)
+
+const (
+	logName      = "wal"
+	snapshotName = "snapshot"
+
+	snapshotMagic   = "DSSNAP\x00\x01"
+	frameHeaderSize = 8
+
+	DefaultCompactEvery = 1000
+)
+
+var (
+	// ErrCorrupt is returned by Open when the snapshot fails validation.
+	ErrCorrupt = errors.New("durable: snapshot is corrupt")
+
+	// ErrClosed is returned by operations on a closed map.
+	ErrClosed = errors.New("durable: map is closed")
+)
+
+type Options struct {
+	// CompactEvery is the number of log records after which the log is
+	// compacted into a snapshot. It defaults to DefaultCompactEvery, a
+	// negative value disables automatic compaction.
+	CompactEvery int
+
+	// NoSync skips flushing writes to stable storage. A crash of the
+	// operating system may then lose the most recent changes.
+	NoSync bool
+}
+
+// Map is an ordered map stored in a directory. It is not safe for concurrent use.
+type Map[K cmp.Ordered, V any] struct {
+	tree    *bst.Tree[K, V]
+	dir     string
+	log     *os.File
+	offset  int64
+	records int
+	opts    Options
+	err     error
+}
+
+// record is a change stored in the log. Replaying a record is idempotent, so
+// a log may safely be replayed on top of a snapshot that already contains it.
+type record[K any, V any] struct {
+	Remove bool
+	Key    K
+	Val    V
+}
+
+// Open opens the map stored in dir, creating the directory if it does not
+// exist. A nil opts uses the defaults.
+func Open[K cmp.Ordered, V any](dir string, opts *Options) (*Map[K, V], error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	// Remember which directories MkdirAll creates: their entries only become
+	// durable once their parents are synced.
+	var created []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		created = append(created, d)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	m := &Map[K, V]{
+		tree: bst.New[K, V](),
+		dir:  dir,
+		opts: *opts,
+	}
+	if m.opts.CompactEvery == 0 {
+		m.opts.CompactEvery = DefaultCompactEvery
+	}
+	for _, d := range created {
+		if err := m.syncDir(filepath.Dir(d)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(filepath.Join(dir, logName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	m.log = log
+	// The log may have just been created, so its directory entry is synced
+	// before any record is.
+	if err := m.syncDir(m.dir); err != nil {
+		log.Close()
+		return nil, err
+	}
+	if err := m.replay(); err != nil {
+		log.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Insert adds key with data to the map, leaving an existing key unchanged.
+func (m *Map[K, V]) Insert(key K, data V) error {
+	if m.tree.Find(key) != nil {
+		return m.err
+	}
+	return m.apply(record[K, V]{Key: key, Val: data})
+}
+
+// Put sets the value for key, inserting it if missing.
+func (m *Map[K, V]) Put(key K, data V) error {
+	return m.apply(record[K, V]{Key: key, Val: data})
+}
+
+func (m *Map[K, V]) Remove(key K) error {
+	if m.tree.Find(key) == nil {
+		return m.err
+	}
+	return m.apply(record[K, V]{Remove: true, Key: key})
+}
+
+func (m *Map[K, V]) Find(key K) *bst.Node[K, V] {
+	return m.tree.Find(key)
+}
+
+func (m *Map[K, V]) Size() int {
+	return m.tree.Size()
+}
+
+func (m *Map[K, V]) Keys() []K {
+	return m.tree.Keys()
+}
+
+// All returns an iterator over the key-value pairs of the map in ascending key order.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return m.tree.All()
+}
+
+// Range returns an iterator over the key-value pairs with lo <= key <= hi in ascending key order.
+func (m *Map[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return m.tree.Range(lo, hi)
+}
+
+// Compact writes the map to a new snapshot and empties the log.
+func (m *Map[K, V]) Compact() error {
+	if m.err != nil {
+		return m.err
+	}
+
+	data, err := m.tree.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 0, len(snapshotMagic)+4+len(data))
+	buf = append(buf, snapshotMagic...)
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(data))
+	buf = append(buf, data...)
+
+	// The new snapshot replaces the old one atomically. A crash before the log
+	// is truncated leaves records that are already in the snapshot, which is
+	// harmless since replaying them gives the same result.
+	path := filepath.Join(m.dir, snapshotName)
+	if err := m.writeFile(path+".tmp", buf); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	if err := m.syncDir(m.dir); err != nil {
+		return err
+	}
+
+	if err := m.log.Truncate(0); err != nil {
+		return m.fail(err)
+	}
+	if err := m.sync(m.log); err != nil {
+		return m.fail(err)
+	}
+	m.offset = 0
+	m.records = 0
+	return nil
+}
+
+// Close closes the log file.
+func (m *Map[K, V]) Close() error {
+	if errors.Is(m.err, ErrClosed) {
+		return ErrClosed
+	}
+	err := m.log.Close()
+	m.err = ErrClosed
+	return err
+}
+
+// apply appends rec to the log and then applies it to the tree.
+func (m *Map[K, V]) apply(rec record[K, V]) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(rec); err != nil {
+		return fmt.Errorf("durable: encoding record: %w", err)
+	}
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+payload.Len())
+	binary.LittleEndian.PutUint32(frame, uint32(payload.Len()))
+	binary.LittleEndian.PutUint32(frame[4:], crc32.ChecksumIEEE(payload.Bytes()))
+	frame = append(frame, payload.Bytes()...)
+
+	_, err := m.log.WriteAt(frame, m.offset)
+	if err == nil {
+		err = m.sync(m.log)
+	}
+	if err != nil {
+		// Drop a partial record so that later records are not hidden behind it.
+		if terr := m.log.Truncate(m.offset); terr != nil {
+			m.fail(terr)
+		}
+		return err
+	}
+	m.offset += int64(len(frame))
+	m.records++
+
+	m.applyRecord(rec)
+
+	// A failed compaction leaves the log intact and is retried on the next
+	// write. Call Compact to observe the error.
+	if m.opts.CompactEvery > 0 && m.records >= m.opts.CompactEvery {
+		m.Compact()
+	}
+	return nil
+}
+
+func (m *Map[K, V]) applyRecord(rec record[K, V]) {
+	if rec.Remove {
+		m.tree.Remove(rec.Key)
+	} else {
+		m.tree.Put(rec.Key, rec.Val)
+	}
+}
+
+func (m *Map[K, V]) loadSnapshot() error {
+	buf, err := os.ReadFile(filepath.Join(m.dir, snapshotName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	n := len(snapshotMagic)
+	if len(buf) < n+4 || string(buf[:n]) != snapshotMagic {
+		return ErrCorrupt
+	}
+	data := buf[n+4:]
+	if binary.LittleEndian.Uint32(buf[n:]) != crc32.ChecksumIEEE(data) {
+		return ErrCorrupt
+	}
+	if err := m.tree.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return nil
+}
+
+// replay applies the records of the log to the tree. The log ends at the first
+// incomplete record or checksum mismatch, which is what a crash in the middle
+// of a write leaves behind, and the rest of the file is dropped.
+func (m *Map[K, V]) replay() error {
+	data, err := io.ReadAll(m.log)
+	if err != nil {
+		return err
+	}
+
+	off := 0
+	for off+frameHeaderSize <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[off:]))
+		sum := binary.LittleEndian.Uint32(data[off+4:])
+		end := off + frameHeaderSize + size
+		if end > len(data) || crc32.ChecksumIEEE(data[off+frameHeaderSize:end]) != sum {
+			break
+		}
+
+		var rec record[K, V]
+		if err := gob.NewDecoder(bytes.NewReader(data[off+frameHeaderSize : end])).Decode(&rec); err != nil {
+			return fmt.Errorf("durable: decoding log record at offset %d: %w", off, err)
+		}
+		m.applyRecord(rec)
+		m.records++
+		off = end
+	}
+
+	if off < len(data) {
+		if err := m.log.Truncate(int64(off)); err != nil {
+			return err
+		}
+	}
+	m.offset = int64(off)
+	return nil
+}
+
+func (m *Map[K, V]) writeFile(path string, data []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = m.sync(f)
+	}
+	return errors.Join(err, f.Close())
+}
+
+func (m *Map[K, V]) syncDir(path string) error {
+	if m.opts.NoSync {
+		return nil
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return errors.Join(dir.Sync(), dir.Close())
+}
+
+func (m *Map[K, V]) sync(f *os.File) error {
+	if m.opts.NoSync {
+		return nil
+	}
+	return f.Sync()
+}
+
+func (m *Map[K, V]) fail(err error) error {
+	if m.err == nil {
+		m.err = err
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package durable
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func openTestMap(t *testing.T, dir string, compactEvery int) *Map[string, int] {
+	t.Helper()
+	m, err := Open[string, int](dir, &Options{CompactEvery: compactEvery, NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func checkEntries(t *testing.T, m *Map[string, int], want map[string]int) {
+	t.Helper()
+	if m.Size() != len(want) {
+		t.Errorf("Expected size to be %d, got %d instead", len(want), m.Size())
+	}
+	for k, v := range want {
+		if node := m.Find(k); node == nil || node.Data() != v {
+			t.Errorf("Expected %s to be %d, got %v instead", k, v, node)
+		}
+	}
+}
+
+func TestMapReopen(t *testing.T) {
+	dir := t.TempDir()
+	m := openTestMap(t, dir, -1)
+
+	m.Insert("a", 1)
+	m.Insert("b", 2)
+	m.Insert("a", 100)
+	m.Put("c", 3)
+	m.Put("c", 4)
+	m.Remove("b")
+	m.Remove("missing")
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Put("d", 5); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v instead", err)
+	}
+
+	m = openTestMap(t, dir, -1)
+	defer m.Close()
+	checkEntries(t, m, map[string]int{"a": 1, "c": 4})
+	if m.records != 5 {
+		t.Errorf("Expected 5 records in the log, got %d instead", m.records)
+	}
+}
+
+func TestMapCreateDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+
+	// Syncing is on, so the new directories and the log are synced as well.
+	m, err := Open[string, int](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Put("a", 1)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m = openTestMap(t, dir, -1)
+	defer m.Close()
+	checkEntries(t, m, map[string]int{"a": 1})
+}
+
+func TestMapTornLog(t *testing.T) {
+	dir := t.TempDir()
+	m := openTestMap(t, dir, -1)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Close()
+
+	// Cut the last record short, as a crash in the middle of a write would.
+	path := filepath.Join(dir, logName)
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-3)
+
+	m = openTestMap(t, dir, -1)
+	checkEntries(t, m, map[string]int{"a": 1})
+	m.Put("c", 3)
+	m.Close()
+
+	m = openTestMap(t, dir, -1)
+	defer m.Close()
+	checkEntries(t, m, map[string]int{"a": 1, "c": 3})
+}
+
+func TestMapCompact(t *testing.T) {
+	dir := t.TempDir()
+	m := openTestMap(t, dir, 10)
+
+	want := make(map[string]int)
+	for i := range 25 {
+		k := string(rune('a' + i%7))
+		m.Put(k, i)
+		want[k] = i
+	}
+	if m.records != 5 {
+		t.Errorf("Expected 5 records after compaction, got %d instead", m.records)
+	}
+	m.Close()
+
+	m = openTestMap(t, dir, 10)
+	checkEntries(t, m, want)
+
+	// A crash after the snapshot is written but before the log is truncated
+	// leaves old records behind, which replay to the same state.
+	logPath := filepath.Join(dir, logName)
+	stale, _ := os.ReadFile(logPath)
+	m.Put("z", 26)
+	want["z"] = 26
+	if err := m.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+	os.WriteFile(logPath, stale, 0o644)
+
+	m = openTestMap(t, dir, 10)
+	defer m.Close()
+	checkEntries(t, m, want)
+	if keys := m.Keys(); !slices.IsSorted(keys) {
+		t.Errorf("Expected keys in ascending order, got %v instead", keys)
+	}
+}
+
+func TestMapCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	m := openTestMap(t, dir, -1)
+	m.Put("a", 1)
+	m.Compact()
+	m.Close()
+
+	path := filepath.Join(dir, snapshotName)
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+
+	if _, err := Open[string, int](dir, nil); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v instead", err)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+// Package interval implements an interval tree on top of the AVL tree of
+// package bst. Every node is augmented with the greatest upper endpoint in its
+// subtree, which lets overlap queries skip whole subtrees.
+package interval
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+
+	"github.com/nmezhenskyi/ds/bst"
+)
+
+// Interval is a closed interval [Lo, Hi].
+type Interval[K cmp.Ordered] struct {
+	Lo K
+	Hi K
+}
+
+func (iv Interval[K]) String() string {
+	return fmt.Sprintf("[%v, %v]", iv.Lo, iv.Hi)
+}
+
+// Overlaps reports whether iv and other share at least one point.
+func (iv Interval[K]) Overlaps(other Interval[K]) bool {
+	return iv.Lo <= other.Hi && other.Lo <= iv.Hi
+}
+
+// Tree stores values keyed by intervals ordered by their lower and then their
+// upper endpoint. The zero Tree is empty and ready to use.
+type Tree[K cmp.Ordered, V any] struct {
+	tree *bst.FuncTree[Interval[K], entry[K, V]]
+}
+
+// entry is the value stored in the underlying tree: the value of the interval
+// and the greatest upper endpoint in its subtree.
+type entry[K cmp.Ordered, V any] struct {
+	val V
+	max K
+}
+
+// Insert stores data for the interval [lo, hi], replacing the value of an
+// identical interval if one is already present.
+func (tree *Tree[K, V]) Insert(lo, hi K, data V) {
+	if tree == nil {
+		panic("interval: called Insert() on a nil tree")
+	}
+	if lo > hi {
+		panic("interval: called Insert() with lo greater than hi")
+	}
+	if tree.tree == nil {
+		tree.tree = bst.NewFuncAugmented(compare[K], fix[K, V])
+	}
+	tree.tree.Put(Interval[K]{lo, hi}, entry[K, V]{val: data})
+}
+
+// Get returns the value of the interval [lo, hi] and whether it is present.
+func (tree *Tree[K, V]) Get(lo, hi K) (val V, ok bool) {
+	if tree == nil {
+		panic("interval: called Get() on a nil tree")
+	}
+	if tree.tree == nil {
+		return val, false
+	}
+	e, ok := tree.tree.Get(Interval[K]{lo, hi})
+	return e.val, ok
+}
+
+// Delete removes the interval [lo, hi] and reports whether it was present.
+func (tree *Tree[K, V]) Delete(lo, hi K) bool {
+	if tree == nil {
+		panic("interval: called Delete() on a nil tree")
+	}
+	if tree.tree == nil {
+		return false
+	}
+	size := tree.tree.Size()
+	tree.tree.Remove(Interval[K]{lo, hi})
+	return tree.tree.Size() < size
+}
+
+func (tree *Tree[K, V]) Height() int {
+	if tree == nil {
+		return 0
+	}
+	return tree.tree.Height()
+}
+
+func (tree *Tree[K, V]) Size() int {
+	if tree == nil {
+		return 0
+	}
+	return tree.tree.Size()
+}
+
+// All returns an iterator over every interval and its value in ascending order.
+func (tree *Tree[K, V]) All() iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		if tree == nil {
+			return
+		}
+		for iv, e := range tree.tree.All() {
+			if !yield(iv, e.val) {
+				return
+			}
+		}
+	}
+}
+
+// Stab returns an iterator over the intervals that contain point.
+func (tree *Tree[K, V]) Stab(point K) iter.Seq2[Interval[K], V] {
+	return tree.Overlap(point, point)
+}
+
+// Overlap returns an iterator over the intervals that share at least one point
+// with [lo, hi], in ascending order.
+func (tree *Tree[K, V]) Overlap(lo, hi K) iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		if tree == nil || lo > hi {
+			return
+		}
+		overlap(tree.tree.Root(), Interval[K]{lo, hi}, yield)
+	}
+}
+
+// Validate checks the balance of the tree and the cached upper endpoints.
+func (tree *Tree[K, V]) Validate() error {
+	if tree == nil || tree.tree.Root() == nil {
+		return nil
+	}
+	if err := tree.tree.Validate(); err != nil {
+		return err
+	}
+	_, err := validate(tree.tree.Root())
+	return err
+}
+
+func compare[K cmp.Ordered](a, b Interval[K]) int {
+	if c := cmp.Compare(a.Lo, b.Lo); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Hi, b.Hi)
+}
+
+// fix recomputes the greatest upper endpoint of a node from its children.
+func fix[K cmp.Ordered, V any](iv Interval[K], data *entry[K, V], left, right *entry[K, V]) {
+	data.max = iv.Hi
+	if left != nil && left.max > data.max {
+		data.max = left.max
+	}
+	if right != nil && right.max > data.max {
+		data.max = right.max
+	}
+}
+
+// overlap yields the intervals of the subtree that overlap q, skipping every
+// subtree whose greatest endpoint lies before q and every right subtree whose
+// intervals all start after q.
+func overlap[K cmp.Ordered, V any](node *bst.Node[Interval[K], entry[K, V]], q Interval[K], yield func(Interval[K], V) bool) bool {
+	if node == nil || node.Data().max < q.Lo {
+		return true
+	}
+	if !overlap(node.Left(), q, yield) {
+		return false
+	}
+	iv := node.Key()
+	if iv.Overlaps(q) && !yield(iv, node.Data().val) {
+		return false
+	}
+	if iv.Lo > q.Hi {
+		return true
+	}
+	return overlap(node.Right(), q, yield)
+}
+
+// validate checks the cached upper endpoints of the subtree and returns its
+// greatest upper endpoint.
+func validate[K cmp.Ordered, V any](node *bst.Node[Interval[K], entry[K, V]]) (K, error) {
+	iv := node.Key()
+	m := iv.Hi
+	for _, child := range []*bst.Node[Interval[K], entry[K, V]]{node.Left(), node.Right()} {
+		if child == nil {
+			continue
+		}
+		cm, err := validate(child)
+		if err != nil {
+			return m, err
+		}
+		m = max(m, cm)
+	}
+	if got := node.Data().max; got != m {
+		return m, fmt.Errorf("interval: node %v has max %v, expected %v", iv, got, m)
+	}
+	return m, nil
+}
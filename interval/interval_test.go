@@ -0,0 +1,165 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package interval
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTreeInsert(t *testing.T) {
+	tree := Tree[int, string]{}
+
+	tree.Insert(5, 10, "a")
+	tree.Insert(1, 3, "b")
+	tree.Insert(5, 10, "c")
+
+	if n := tree.Size(); n != 2 {
+		t.Fatalf("Expected size to be 2, got %d instead", n)
+	}
+	if v, ok := tree.Get(5, 10); !ok || v != "c" {
+		t.Errorf("Expected [5, 10] to hold \"c\", got %q instead", v)
+	}
+	if _, ok := tree.Get(5, 9); ok {
+		t.Error("Expected [5, 9] not to be found")
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTreeInsertInvalid(t *testing.T) {
+	tree := Tree[int, string]{}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected to recover from panic after tree.Insert(3, 1, \"\"), got nil instead")
+		}
+	}()
+
+	tree.Insert(3, 1, "")
+}
+
+func TestTreeOverlap(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tree := Tree[int, int]{}
+	var ivs []Interval[int]
+
+	for i := 0; i < 500; i++ {
+		lo := rng.Intn(1000)
+		hi := lo + rng.Intn(50)
+		if _, ok := tree.Get(lo, hi); !ok {
+			ivs = append(ivs, Interval[int]{lo, hi})
+		}
+		tree.Insert(lo, hi, i)
+	}
+	for i := 0; i < len(ivs); i += 3 {
+		if !tree.Delete(ivs[i].Lo, ivs[i].Hi) {
+			t.Fatalf("Expected Delete(%v) to report a removed interval", ivs[i])
+		}
+	}
+	if tree.Delete(-5, -1) {
+		t.Error("Expected Delete() of a missing interval to report false")
+	}
+
+	live := map[Interval[int]]bool{}
+	for i, iv := range ivs {
+		if i%3 != 0 {
+			live[iv] = true
+		}
+	}
+	if n := tree.Size(); n != len(live) {
+		t.Fatalf("Expected size to be %d, got %d instead", len(live), n)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 200; i++ {
+		lo := rng.Intn(1100) - 50
+		hi := lo + rng.Intn(30)
+		q := Interval[int]{lo, hi}
+
+		expected := 0
+		for iv := range live {
+			if iv.Overlaps(q) {
+				expected++
+			}
+		}
+
+		got := 0
+		var prev *Interval[int]
+		for iv := range tree.Overlap(lo, hi) {
+			if !iv.Overlaps(q) {
+				t.Fatalf("Overlap(%d, %d) returned non-overlapping %v", lo, hi, iv)
+			}
+			if prev != nil && compare(*prev, iv) >= 0 {
+				t.Fatalf("Overlap(%d, %d) returned %v after %v", lo, hi, iv, *prev)
+			}
+			prev = &iv
+			got++
+		}
+		if got != expected {
+			t.Fatalf("Expected Overlap(%d, %d) to return %d intervals, got %d instead", lo, hi, expected, got)
+		}
+	}
+}
+
+func TestTreeStab(t *testing.T) {
+	tree := Tree[int, string]{}
+	tree.Insert(1, 5, "a")
+	tree.Insert(3, 8, "b")
+	tree.Insert(6, 9, "c")
+	tree.Insert(10, 12, "d")
+
+	var got []string
+	for _, v := range tree.Stab(5) {
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Expected Stab(5) to return [a b], got %v instead", got)
+	}
+
+	for iv := range tree.Stab(9) {
+		if iv != (Interval[int]{6, 9}) {
+			t.Errorf("Expected Stab(9) to return [6, 9], got %v instead", iv)
+		}
+		break
+	}
+}
+
+func TestTreeZero(t *testing.T) {
+	tree := Tree[int, int]{}
+
+	if _, ok := tree.Get(1, 2); ok {
+		t.Error("Expected Get() on an empty tree to report a missing interval")
+	}
+	if tree.Delete(1, 2) {
+		t.Error("Expected Delete() on an empty tree to report false")
+	}
+	for iv := range tree.Overlap(0, 10) {
+		t.Errorf("Expected no intervals, got %v instead", iv)
+	}
+	if tree.Size() != 0 || tree.Height() != 0 || tree.Validate() != nil {
+		t.Errorf("Expected an empty valid tree, got size %d and height %d instead", tree.Size(), tree.Height())
+	}
+}
@@ -0,0 +1,210 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+// Package ttlmap implements an ordered map with per-key expiration. Keys are
+// stored in an AVL tree alongside a second tree that orders them by expiry
+// time, so expired keys are found without scanning the whole map.
+package ttlmap
+
+import (
+	"cmp"
+	"iter"
+	"time"
+
+	"github.com/nmezhenskyi/ds/bst"
+)
+
+// Clock is the source of the current time used to decide whether keys expired.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Map is an ordered map whose keys may expire. Expired keys are removed lazily
+// when they are looked up and eagerly by Sweep. The zero Map is empty, ready
+// to use and reads the system clock. A Map is not safe for concurrent use.
+type Map[K cmp.Ordered, V any] struct {
+	entries *bst.Tree[K, entry[V]]
+	expiry  *bst.FuncTree[deadline[K], struct{}]
+	clock   Clock
+	onEvict func(key K, val V)
+}
+
+type entry[V any] struct {
+	val     V
+	expires time.Time
+}
+
+// deadline orders the expiry index by time and then by key, so keys that
+// expire at the same instant do not collide.
+type deadline[K cmp.Ordered] struct {
+	at  time.Time
+	key K
+}
+
+// New returns an empty map that reads the current time from clock, or from
+// the system clock if clock is nil.
+func New[K cmp.Ordered, V any](clock Clock) *Map[K, V] {
+	m := &Map[K, V]{clock: clock}
+	m.lazyInit()
+	return m
+}
+
+// lazyInit creates the indexes of a zero Map.
+func (m *Map[K, V]) lazyInit() {
+	if m.entries != nil {
+		return
+	}
+	m.entries = bst.New[K, entry[V]]()
+	m.expiry = bst.NewFunc[deadline[K], struct{}](func(a, b deadline[K]) int {
+		if c := a.at.Compare(b.at); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.key, b.key)
+	})
+	if m.clock == nil {
+		m.clock = systemClock{}
+	}
+}
+
+// OnEvict registers fn to be called for every key removed because it expired.
+// It is not called for keys removed by Delete or replaced by Set.
+func (m *Map[K, V]) OnEvict(fn func(key K, val V)) {
+	m.onEvict = fn
+}
+
+// Set stores val for key without an expiry time.
+func (m *Map[K, V]) Set(key K, val V) {
+	m.set(key, entry[V]{val: val})
+}
+
+// SetWithTTL stores val for key until ttl has passed. A non-positive ttl
+// stores an entry that is already expired.
+func (m *Map[K, V]) SetWithTTL(key K, val V, ttl time.Duration) {
+	m.lazyInit()
+	m.set(key, entry[V]{val: val, expires: m.clock.Now().Add(ttl)})
+}
+
+// Get returns the value of key if it is present and has not expired. An
+// expired key is evicted.
+func (m *Map[K, V]) Get(key K) (val V, ok bool) {
+	m.lazyInit()
+	node := m.entries.Find(key)
+	if node == nil {
+		return val, false
+	}
+	if e := node.Data(); m.expired(e, m.clock.Now()) {
+		m.evict(key, e)
+		return val, false
+	}
+	return node.Data().val, true
+}
+
+// TTL returns the time left until key expires. The result ok is false if key
+// is missing or expired; a key without expiry time has a zero TTL.
+func (m *Map[K, V]) TTL(key K) (ttl time.Duration, ok bool) {
+	m.lazyInit()
+	node := m.entries.Find(key)
+	if node == nil {
+		return 0, false
+	}
+	e, now := node.Data(), m.clock.Now()
+	if m.expired(e, now) {
+		return 0, false
+	}
+	if e.expires.IsZero() {
+		return 0, true
+	}
+	return e.expires.Sub(now), true
+}
+
+// Delete removes key and reports whether it was present.
+func (m *Map[K, V]) Delete(key K) bool {
+	m.lazyInit()
+	e, ok := m.entries.Delete(key)
+	if ok && !e.expires.IsZero() {
+		m.expiry.Remove(deadline[K]{e.expires, key})
+	}
+	return ok
+}
+
+// Sweep evicts every key that expired at or before now and returns how many
+// keys were evicted.
+func (m *Map[K, V]) Sweep(now time.Time) int {
+	m.lazyInit()
+	n := 0
+	for min := m.expiry.Min(); min != nil && !min.Key().at.After(now); min = m.expiry.Min() {
+		key := min.Key().key
+		m.evict(key, m.entries.Find(key).Data())
+		n++
+	}
+	return n
+}
+
+// Len returns the number of keys, including expired keys that were not evicted yet.
+func (m *Map[K, V]) Len() int {
+	m.lazyInit()
+	return m.entries.Size()
+}
+
+// All returns an iterator over the keys that have not expired and their values
+// in ascending key order. Expired keys are skipped but not evicted.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.lazyInit()
+		now := m.clock.Now()
+		for k, e := range m.entries.All() {
+			if m.expired(e, now) {
+				continue
+			}
+			if !yield(k, e.val) {
+				return
+			}
+		}
+	}
+}
+
+func (m *Map[K, V]) set(key K, e entry[V]) {
+	m.lazyInit()
+	if old, replaced := m.entries.Put(key, e); replaced && !old.expires.IsZero() {
+		m.expiry.Remove(deadline[K]{old.expires, key})
+	}
+	if !e.expires.IsZero() {
+		m.expiry.Insert(deadline[K]{e.expires, key}, struct{}{})
+	}
+}
+
+func (m *Map[K, V]) expired(e entry[V], now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+func (m *Map[K, V]) evict(key K, e entry[V]) {
+	m.entries.Remove(key)
+	m.expiry.Remove(deadline[K]{e.expires, key})
+	if m.onEvict != nil {
+		m.onEvict(key, e.val)
+	}
+}
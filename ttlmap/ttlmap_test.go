@@ -0,0 +1,137 @@
+/*
+	The MIT License (MIT)
+
+	Copyright (c) 2024 Nikita Mezhenskyi
+
+	Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+	and associated documentation files (the "Software"), to deal in the Software without restriction,
+	including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+	and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+	subject to the following conditions:
+
+	The above copyright notice and this permission notice shall be included in all copies or
+	substantial portions of the Software.
+
+	THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+	INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+	NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM,
+	DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+	OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package ttlmap
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestMap() (*Map[string, int], *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return New[string, int](clock), clock
+}
+
+func TestMapGet(t *testing.T) {
+	m, clock := newTestMap()
+
+	m.SetWithTTL("a", 1, time.Minute)
+	m.Set("b", 2)
+
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Fatalf("Expected Get(\"a\") to be (1, true), got (%d, %t) instead", v, ok)
+	}
+	if ttl, ok := m.TTL("a"); !ok || ttl != time.Minute {
+		t.Errorf("Expected TTL(\"a\") to be 1m, got %v instead", ttl)
+	}
+
+	clock.Advance(time.Minute)
+
+	if _, ok := m.Get("a"); ok {
+		t.Error("Expected \"a\" to be expired")
+	}
+	if v, ok := m.Get("b"); !ok || v != 2 {
+		t.Errorf("Expected Get(\"b\") to be (2, true), got (%d, %t) instead", v, ok)
+	}
+	if n := m.Len(); n != 1 {
+		t.Errorf("Expected length to be 1 after lazy expiry, got %d instead", n)
+	}
+}
+
+func TestMapSweep(t *testing.T) {
+	m, clock := newTestMap()
+
+	var evicted []string
+	m.OnEvict(func(key string, val int) {
+		evicted = append(evicted, key)
+	})
+
+	m.SetWithTTL("c", 3, 3*time.Second)
+	m.SetWithTTL("a", 1, time.Second)
+	m.SetWithTTL("b", 2, 2*time.Second)
+	m.SetWithTTL("d", 4, time.Second)
+	m.Set("e", 5)
+	m.SetWithTTL("b", 2, 10*time.Second)
+
+	clock.Advance(2 * time.Second)
+
+	if n := m.Sweep(clock.Now()); n != 2 {
+		t.Fatalf("Expected Sweep() to evict 2 keys, got %d instead", n)
+	}
+	if len(evicted) != 2 || evicted[0] != "a" || evicted[1] != "d" {
+		t.Errorf("Expected evicted keys [a d], got %v instead", evicted)
+	}
+
+	var keys []string
+	for k := range m.All() {
+		keys = append(keys, k)
+	}
+	if len(keys) != 3 || keys[0] != "b" || keys[1] != "c" || keys[2] != "e" {
+		t.Errorf("Expected keys [b c e], got %v instead", keys)
+	}
+
+	m.Delete("c")
+	if n := m.Sweep(clock.Now().Add(time.Hour)); n != 1 {
+		t.Errorf("Expected Sweep() to evict 1 key, got %d instead", n)
+	}
+	if n := m.Len(); n != 1 {
+		t.Errorf("Expected length to be 1, got %d instead", n)
+	}
+}
+
+func TestMapZero(t *testing.T) {
+	var m Map[string, int]
+
+	if _, ok := m.Get("a"); ok {
+		t.Error("Expected \"a\" to be missing from the zero map")
+	}
+	if n := m.Len(); n != 0 {
+		t.Errorf("Expected length to be 0, got %d instead", n)
+	}
+
+	m.Set("a", 1)
+	m.SetWithTTL("b", 2, time.Hour)
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Errorf("Expected Get(\"a\") to be (1, true), got (%d, %t) instead", v, ok)
+	}
+	if ttl, ok := m.TTL("b"); !ok || ttl <= 0 || ttl > time.Hour {
+		t.Errorf("Expected TTL(\"b\") to be at most 1h, got %v instead", ttl)
+	}
+	if n := m.Sweep(time.Now().Add(2 * time.Hour)); n != 1 {
+		t.Errorf("Expected Sweep() to evict 1 key, got %d instead", n)
+	}
+	if !m.Delete("a") || m.Len() != 0 {
+		t.Errorf("Expected the map to be empty, got length %d instead", m.Len())
+	}
+}